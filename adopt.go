@@ -0,0 +1,142 @@
+package process
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+)
+
+// adoptPollInterval is the period of liveness checks of adopted children
+const adoptPollInterval = 200 * time.Millisecond
+
+// Adopted is a child left running by a previous supervisor instance. It's not
+// a child of the current process, so its exit status is unknown: liveness is
+// polled and the record in the store is updated once it's gone.
+type Adopted struct {
+	store Store
+	done  chan struct{}
+
+	mu     sync.RWMutex
+	record Record
+	since  time.Time
+}
+
+// Adopt reconciles the store like Reconcile and returns handles watching
+// the children that are still running
+func Adopt(s Store) ([]*Adopted, error) {
+	live, err := Reconcile(s)
+	res := make([]*Adopted, 0, len(live))
+	for _, r := range live {
+		a := &Adopted{store: s, done: make(chan struct{}), record: r, since: time.Now()}
+		go a.watch()
+		res = append(res, a)
+	}
+	return res, err
+}
+
+// Name returns the record name of the child
+func (a *Adopted) Name() string {
+	return a.record.Name
+}
+
+// Status returns the last known status of the child
+func (a *Adopted) Status() Status {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return Status{
+		State:        a.record.State,
+		Pid:          a.record.Pid,
+		RunID:        a.record.RunID,
+		RestartCount: a.record.RestartCount,
+		Since:        a.since,
+		Uptime:       a.record.Uptime,
+		Downtime:     a.record.Downtime,
+		CleanExits:   a.record.CleanExits,
+		CrashExits:   a.record.CrashExits,
+	}
+}
+
+// Done returns a channel closed when the child is gone
+func (a *Adopted) Done() <-chan struct{} {
+	return a.done
+}
+
+// Wait blocks until the child is gone or the context is done
+func (a *Adopted) Wait(ctx context.Context) error {
+	select {
+	case <-a.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stop interrupts the child and kills it after StopTimeout of its spec. The
+// call blocks until the child is gone or KillTimeout after the kill signal
+// has passed.
+func (a *Adopted) Stop() error {
+	st := a.Status()
+	if st.State.terminal() {
+		return nil
+	}
+	proc, err := os.FindProcess(st.Pid)
+	if err != nil {
+		return err
+	}
+	defer proc.Release()
+	spec := a.record.Spec
+	a.setState(StateStopping)
+	if err := interrupt(proc); err != nil {
+		return err
+	}
+	select {
+	case <-a.done:
+		return nil
+	case <-time.After(timeoutOr(spec.StopTimeout, stopTimeout)):
+	}
+	a.setState(StateKilling)
+	if err := proc.Kill(); err != nil {
+		return err
+	}
+	select {
+	case <-a.done:
+		return ErrStopTimeout
+	case <-time.After(timeoutOr(spec.KillTimeout, killTimeout)):
+		return ErrKillFailed
+	}
+}
+
+func (a *Adopted) setState(s State) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.record.State.terminal() {
+		a.record.State, a.since = s, time.Now()
+	}
+}
+
+// watch polls the child until it's gone and saves the final record
+func (a *Adopted) watch() {
+	t := time.NewTicker(adoptPollInterval)
+	defer t.Stop()
+	for range t.C {
+		if processAlive(a.record.Pid, a.record.RunID) {
+			continue
+		}
+		a.mu.Lock()
+		a.record.Pid, a.record.State = 0, StateStopped
+		a.record.UpdatedAt, a.since = time.Now(), time.Now()
+		r := a.record
+		a.mu.Unlock()
+		a.store.Save(r)
+		close(a.done)
+		return
+	}
+}
+
+func timeoutOr(ms, def int) time.Duration {
+	if ms <= 0 {
+		ms = def
+	}
+	return time.Duration(ms) * time.Millisecond
+}
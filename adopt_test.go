@@ -0,0 +1,58 @@
+package process_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/andviro/process"
+)
+
+func TestAdopt(t *testing.T) {
+	s, err := process.OpenFileStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The previous supervisor instance is simulated by a process of this one,
+	// which reaps the child once it's stopped through the adopted handle
+	p := &process.Process{Spec: process.Spec{
+		Cmd:          "/bin/sleep",
+		Args:         []string{"5"},
+		StartTimeout: 50,
+		StopTimeout:  1000,
+	}}
+	res := p.Run(context.TODO())
+	defer p.Stop()
+	time.Sleep(100 * time.Millisecond)
+	if err := s.Save(p.Record("sleeper")); err != nil {
+		t.Fatal(err)
+	}
+
+	adopted, err := process.Adopt(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(adopted) != 1 || adopted[0].Name() != "sleeper" {
+		t.Fatalf("invalid adopted: %v", adopted)
+	}
+	a := adopted[0]
+	if st := a.Status(); st.State != process.StateRunning || st.Pid == 0 {
+		t.Errorf("invalid status: %+v", st)
+	}
+	if err := a.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	<-res
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second)
+	defer cancel()
+	if err := a.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if st := a.Status(); st.State != process.StateStopped || st.Pid != 0 {
+		t.Errorf("invalid final status: %+v", st)
+	}
+	if r, err := s.Load("sleeper"); err != nil || r.Pid != 0 || r.State != process.StateStopped {
+		t.Errorf("record not updated: %+v, %v", r, err)
+	}
+}
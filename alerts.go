@@ -0,0 +1,176 @@
+package process
+
+import (
+	"fmt"
+	"time"
+)
+
+// Resource alert kinds
+const (
+	AlertCPU = "cpu" // CPU usage in percent of one core
+	AlertFDs = "fds" // Number of open file descriptors
+
+	AlertUnkillable = "unkillable" // Child survived the kill signals, e.g. stuck in uninterruptible sleep
+)
+
+// Alert reports the child crossing a resource usage threshold
+type Alert struct {
+	Kind  string    `json:"kind"`  // Resource kind
+	RunID string    `json:"runId"` // Identifier of the start attempt
+	Pid   int       `json:"pid"`   // Pid of the child
+	Value float64   `json:"value"` // Measured usage
+	Limit float64   `json:"limit"` // Threshold crossed
+	Time  time.Time `json:"time"`  // Time of the measurement
+}
+
+func (a Alert) String() string {
+	if a.Kind == AlertUnkillable {
+		return fmt.Sprintf("process %d survived kill", a.Pid)
+	}
+	return fmt.Sprintf("%s usage %.1f exceeds %.1f", a.Kind, a.Value, a.Limit)
+}
+
+// CPUThreshold raises an alert when the child CPU usage averaged over the
+// window exceeds the limit. The alert is raised again only after the usage
+// drops below the limit.
+type CPUThreshold struct {
+	Percent float64 `json:"percent"` // CPU usage limit in percent of one core
+	Window  int     `json:"window"`  // Averaging window in milliseconds, 10 seconds by default
+	Action  string  `json:"action"`  // One of: "restart", "unhealthy" or empty to only call OnAlert
+}
+
+func (t CPUThreshold) validate() error {
+	switch {
+	case t.Percent <= 0:
+		return fmt.Errorf("percent must be positive")
+	case t.Window < 0:
+		return fmt.Errorf("window must not be negative")
+	}
+	switch t.Action {
+	case "", ActionRestart, ActionUnhealthy:
+	default:
+		return fmt.Errorf("unknown action %q", t.Action)
+	}
+	return nil
+}
+
+const (
+	defaultCPUWindow = 10000
+	cpuSamples       = 5 // Number of samples per window
+)
+
+// watchCPU samples CPU time of the child until done is closed
+func (p *Process) watchCPU(pid int, runID string, reqs requests, done <-chan struct{}) {
+	t := *p.CPUThreshold
+	window := time.Duration(t.Window) * time.Millisecond
+	if window == 0 {
+		window = defaultCPUWindow * time.Millisecond
+	}
+	ticker := time.NewTicker(window / cpuSamples)
+	defer ticker.Stop()
+	type sample struct {
+		at  time.Time
+		cpu time.Duration
+	}
+	var (
+		samples []sample
+		raised  bool
+	)
+	for {
+		cpu, err := cpuTime(pid)
+		if err != nil {
+			return
+		}
+		now := time.Now()
+		samples = append(samples, sample{now, cpu})
+		if len(samples) > cpuSamples+1 {
+			samples = samples[1:]
+		}
+		if first := samples[0]; len(samples) > cpuSamples {
+			usage := float64(cpu-first.cpu) / float64(now.Sub(first.at)) * 100
+			switch {
+			case usage > t.Percent && !raised:
+				raised = true
+				a := Alert{Kind: AlertCPU, RunID: runID, Pid: pid, Value: usage, Limit: t.Percent, Time: now}
+				p.alert(reqs, t.Action, a)
+			case usage <= t.Percent:
+				raised = false
+			}
+		}
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// FDThreshold raises an alert when the number of files opened by the child
+// approaches its NOFILE limit. The alert is raised again only after the
+// number drops below the threshold.
+type FDThreshold struct {
+	Ratio    float64 `json:"ratio"`    // Fraction of the limit, 0.8 by default
+	Interval int     `json:"interval"` // Sampling interval in milliseconds, 10 seconds by default
+	Action   string  `json:"action"`   // One of: "restart", "unhealthy" or empty to only call OnAlert
+}
+
+func (t FDThreshold) validate() error {
+	switch {
+	case t.Ratio < 0 || t.Ratio > 1:
+		return fmt.Errorf("ratio must be between 0 and 1")
+	case t.Interval < 0:
+		return fmt.Errorf("interval must not be negative")
+	}
+	switch t.Action {
+	case "", ActionRestart, ActionUnhealthy:
+	default:
+		return fmt.Errorf("unknown action %q", t.Action)
+	}
+	return nil
+}
+
+const (
+	defaultFDRatio    = 0.8
+	defaultFDInterval = 10000
+)
+
+// watchFDs samples the number of open files of the child until done is closed
+func (p *Process) watchFDs(pid int, runID string, reqs requests, done <-chan struct{}) {
+	t := *p.FDThreshold
+	if t.Ratio == 0 {
+		t.Ratio = defaultFDRatio
+	}
+	if t.Interval == 0 {
+		t.Interval = defaultFDInterval
+	}
+	ticker := time.NewTicker(time.Duration(t.Interval) * time.Millisecond)
+	defer ticker.Stop()
+	var raised bool
+	for {
+		count, limit, err := fdUsage(pid)
+		if err != nil {
+			return
+		}
+		threshold := t.Ratio * float64(limit)
+		switch {
+		case float64(count) >= threshold && !raised:
+			raised = true
+			a := Alert{Kind: AlertFDs, RunID: runID, Pid: pid, Value: float64(count), Limit: threshold, Time: time.Now()}
+			p.alert(reqs, t.Action, a)
+		case float64(count) < threshold:
+			raised = false
+		}
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Process) alert(reqs requests, action string, a Alert) {
+	if p.OnAlert != nil {
+		p.OnAlert(a)
+	}
+	p.act(reqs, action, a.Kind+" threshold exceeded", a.String())
+}
@@ -0,0 +1,77 @@
+package process_test
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/andviro/process"
+)
+
+func TestCPUThreshold(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("CPU time is only supported on Linux")
+	}
+	alerts := make(chan process.Alert, 10)
+	p := &process.Process{Spec: process.Spec{
+		Cmd:            "/bin/sh",
+		Args:           []string{"-c", "while true; do :; done"},
+		StartTimeout:   10,
+		StopTimeout:    1000,
+		RestartPolicy:  "always",
+		MaxRestarts:    0,
+		RestartTimeout: 10,
+		CPUThreshold: &process.CPUThreshold{
+			Percent: 50,
+			Window:  200,
+			Action:  process.ActionRestart,
+		},
+		OnAlert: func(a process.Alert) { alerts <- a },
+	}}
+	res := <-p.Run(context.TODO())
+	if res.Restarts != 1 {
+		t.Errorf("invalid restarts: %d", res.Restarts)
+	}
+	select {
+	case a := <-alerts:
+		if a.Kind != process.AlertCPU || a.Value <= 50 || a.RunID == "" {
+			t.Errorf("invalid alert: %+v", a)
+		}
+	default:
+		t.Error("no alert raised")
+	}
+}
+
+func TestFDThreshold(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("open files count is only supported on Linux")
+	}
+	alerts := make(chan process.Alert, 10)
+	p := &process.Process{Spec: process.Spec{
+		Cmd:          "/bin/sh",
+		Args:         []string{"-c", "ulimit -n 20; exec 3</dev/null 4</dev/null 5</dev/null 6</dev/null; exec sleep 0.3"},
+		StartTimeout: 10,
+		FDThreshold: &process.FDThreshold{
+			Ratio:    0.25,
+			Interval: 50,
+			Action:   process.ActionUnhealthy,
+		},
+		OnAlert: func(a process.Alert) { alerts <- a },
+	}}
+	res := p.Run(context.TODO())
+	time.Sleep(200 * time.Millisecond)
+	unhealthy := p.Status().Unhealthy
+	<-res
+	select {
+	case a := <-alerts:
+		if a.Kind != process.AlertFDs || a.Value < 5 || a.Limit != 5 {
+			t.Errorf("invalid alert: %+v", a)
+		}
+	default:
+		t.Error("no alert raised")
+	}
+	if unhealthy == "" {
+		t.Error("process is not marked unhealthy")
+	}
+}
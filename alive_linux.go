@@ -0,0 +1,32 @@
+package process
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// processAlive checks the process environment for the run ID. Processes of
+// other users, e.g. ran with Elevate, can only be checked for existence.
+// Zombies are not alive, they only wait to be reaped.
+func processAlive(pid int, runID string) bool {
+	dir := "/proc/" + strconv.Itoa(pid)
+	if stat, err := os.ReadFile(dir + "/stat"); err == nil {
+		if i := bytes.LastIndexByte(stat, ')'); i >= 0 && i+2 < len(stat) && stat[i+2] == 'Z' {
+			return false
+		}
+	}
+	data, err := os.ReadFile(dir + "/environ")
+	switch {
+	case errors.Is(err, os.ErrPermission):
+		err := syscall.Kill(pid, 0)
+		return err == nil || err == syscall.EPERM
+	case err != nil:
+		return false
+	case runID == "":
+		return true
+	}
+	return bytes.Contains(append([]byte{0}, data...), []byte("\x00"+RunIDEnv+"="+runID+"\x00"))
+}
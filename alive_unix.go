@@ -0,0 +1,12 @@
+//go:build !linux && !windows
+
+package process
+
+import (
+	"syscall"
+)
+
+func processAlive(pid int, runID string) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
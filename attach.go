@@ -0,0 +1,182 @@
+package process
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// Window size of a new PTY, see Process.Resize
+const (
+	defaultRows = 24
+	defaultCols = 80
+)
+
+// terminal is the pseudo-terminal of a child running with PTY. Output of the
+// child is passed to the attached sessions besides Stdout.
+type terminal struct {
+	master *os.File
+	done   chan struct{} // Closed after the child has exited and its output ended
+	rec    *castWriter   // Recording of the session, may be nil
+
+	mu       sync.Mutex
+	sessions map[*session]struct{}
+}
+
+// session is an output writer of Attach
+type session struct {
+	w io.Writer
+}
+
+func newTerminal(out *outputPipe, rec *castWriter) *terminal {
+	t := &terminal{master: out.r, done: make(chan struct{}), rec: rec, sessions: make(map[*session]struct{})}
+	out.dst = tee(out.dst, t)
+	return t
+}
+
+// Write copies the output to the recording and the sessions, dropping the
+// failed ones
+func (t *terminal) Write(p []byte) (int, error) {
+	if t.rec != nil {
+		t.rec.Write(p)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for s := range t.sessions {
+		if _, err := s.w.Write(p); err != nil {
+			delete(t.sessions, s)
+		}
+	}
+	return len(p), nil
+}
+
+func (t *terminal) add(w io.Writer) *session {
+	s := &session{w: w}
+	t.mu.Lock()
+	t.sessions[s] = struct{}{}
+	t.mu.Unlock()
+	return s
+}
+
+func (t *terminal) remove(s *session) {
+	t.mu.Lock()
+	delete(t.sessions, s)
+	t.mu.Unlock()
+}
+
+// startPTY starts the child with the slave side of a new pseudo-terminal as
+// stdin, stdout and stderr. The output is read from the master, stdin is
+// written to it.
+func (p *Process) startPTY(stdin io.Reader, stdout io.Writer) ([]*outputPipe, error) {
+	master, slave, err := openPTY()
+	if err != nil {
+		return nil, err
+	}
+	// The kernel default is zero, which confuses full-screen programs
+	if err = setWinsize(master, defaultRows, defaultCols); err != nil {
+		master.Close()
+		slave.Close()
+		return nil, err
+	}
+	p.cmd.Stdin, p.cmd.Stdout, p.cmd.Stderr = slave, slave, slave
+	err = p.cmd.Start()
+	slave.Close()
+	if err != nil {
+		master.Close()
+		return nil, err
+	}
+	if stdin != nil {
+		go io.Copy(master, stdin)
+	}
+	if stdout == nil {
+		stdout = io.Discard
+	}
+	return []*outputPipe{newOutputPipe(StreamStdout, master, stdout)}, nil
+}
+
+// terminal returns the pseudo-terminal of the running child, nil if none
+func (p *Process) terminal() *terminal {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.term
+}
+
+// Resize sets the window size of the terminal of the child running with PTY,
+// e.g. on SIGWINCH of an attached terminal. The child receives SIGWINCH. New
+// terminals have 24 rows and 80 columns.
+func (p *Process) Resize(rows, cols int) error {
+	t := p.terminal()
+	if t == nil {
+		return ErrNoTerminal
+	}
+	if err := setWinsize(t.master, rows, cols); err != nil {
+		return err
+	}
+	if t.rec != nil {
+		t.rec.resize(rows, cols)
+	}
+	return nil
+}
+
+// close ends the session after the output of the child has ended
+func (t *terminal) close() {
+	if t.rec != nil {
+		t.rec.Close()
+	}
+	close(t.done)
+}
+
+// Attach connects an interactive session to the child running with PTY:
+// input read from in is written to the terminal of the child and the output
+// is copied to out besides Stdout. The call returns when the context is
+// done, in ends or the child exits; input read after that is discarded.
+// Sessions can be attached concurrently, their input is interleaved. A slow
+// out delays the output of the child, out is dropped once it fails.
+// Signals are passed by the terminal of the child, e.g. ^C of in raw
+// mode interrupts its foreground process group.
+func (p *Process) Attach(ctx context.Context, in io.Reader, out io.Writer) error {
+	t := p.terminal()
+	if t == nil {
+		return ErrNoTerminal
+	}
+	s := t.add(out)
+	defer t.remove(s)
+	var detached int32
+	defer atomic.StoreInt32(&detached, 1)
+	input := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := in.Read(buf)
+			if atomic.LoadInt32(&detached) != 0 {
+				return
+			}
+			if n > 0 {
+				if t.rec != nil {
+					t.rec.input(buf[:n])
+				}
+				if _, werr := t.master.Write(buf[:n]); werr != nil {
+					input <- werr
+					return
+				}
+			}
+			if err != nil {
+				if err == io.EOF {
+					err = nil
+				}
+				input <- err
+				return
+			}
+		}
+	}()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-input:
+		return err
+	case <-t.done:
+		return nil
+	}
+}
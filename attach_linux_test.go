@@ -0,0 +1,210 @@
+package process_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andviro/process"
+)
+
+func TestPTY(t *testing.T) {
+	var out syncBuffer
+	p := &process.Process{Spec: process.Spec{
+		Cmd:    "/bin/sh",
+		Args:   []string{"-c", "test -t 0 && test -t 1 && test -t 2 && echo tty >&2"},
+		PTY:    true,
+		Stdout: &out,
+	}}
+	res := <-p.Run(context.TODO())
+	if res.Err != nil || out.String() != "tty\r\n" {
+		t.Errorf("invalid result: %v %q", res.Err, out.String())
+	}
+}
+
+func TestAttach(t *testing.T) {
+	var out, session syncBuffer
+	p := &process.Process{Spec: process.Spec{
+		Cmd:    "/bin/sh",
+		Args:   []string{"-c", "stty -echo; echo ready; read line; echo got $line"},
+		PTY:    true,
+		Stdout: &out,
+	}}
+	if err := p.Attach(context.TODO(), strings.NewReader(""), &session); !errors.Is(err, process.ErrNoTerminal) {
+		t.Errorf("invalid error before run: %v", err)
+	}
+	res := p.Run(context.TODO())
+	for !strings.Contains(out.String(), "ready") {
+		time.Sleep(10 * time.Millisecond)
+	}
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second)
+	defer cancel()
+	in, w := io.Pipe()
+	defer w.Close()
+	go w.Write([]byte("hello\n"))
+	if err := p.Attach(ctx, in, &session); err != nil {
+		t.Errorf("attach: %v", err)
+	}
+	if r := <-res; r.Err != nil {
+		t.Errorf("invalid result: %v", r.Err)
+	}
+	if out.String() != "ready\r\ngot hello\r\n" || session.String() != "got hello\r\n" {
+		t.Errorf("invalid output: %q %q", out.String(), session.String())
+	}
+}
+
+func TestControlAttach(t *testing.T) {
+	var s process.Supervisor
+	s.Add("cat", &process.Process{Spec: process.Spec{
+		Cmd:  "/bin/sh",
+		Args: []string{"-c", "stty -echo; echo ready; read line; echo got $line"},
+		PTY:  true,
+	}})
+	s.Add("sleep", &process.Process{Spec: process.Spec{Cmd: "sleep", Args: []string{"1"}}})
+	srv := httptest.NewServer(s.ControlHandler())
+	defer srv.Close()
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	go s.Run(ctx)
+	time.Sleep(100 * time.Millisecond)
+
+	attach := func(name string) (net.Conn, *bufio.Reader, *http.Response) {
+		t.Helper()
+		conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, _ := http.NewRequest("POST", srv.URL+"/processes/"+name+"/attach", nil)
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", process.AttachProtocol)
+		req.Write(conn)
+		br := bufio.NewReader(conn)
+		resp, err := http.ReadResponse(br, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return conn, br, resp
+	}
+	conn, _, resp := attach("sleep")
+	conn.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("invalid status without terminal: %d", resp.StatusCode)
+	}
+	for query, code := range map[string]int{"rows=x": http.StatusBadRequest, "rows=30&cols=100": http.StatusNoContent} {
+		resp, err := http.Post(srv.URL+"/processes/cat/resize?"+query, "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != code {
+			t.Errorf("%s: invalid resize status: %d", query, resp.StatusCode)
+		}
+	}
+	conn, br, resp := attach("cat")
+	defer conn.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("invalid status: %d", resp.StatusCode)
+	}
+	conn.Write([]byte("hello\n"))
+	out, _ := io.ReadAll(br)
+	if string(out) != "got hello\r\n" {
+		t.Errorf("invalid output: %q", out)
+	}
+}
+
+func TestResize(t *testing.T) {
+	var out syncBuffer
+	p := &process.Process{Spec: process.Spec{
+		Cmd:    "/bin/sh",
+		Args:   []string{"-c", "stty -echo; stty size; read line; stty size"},
+		PTY:    true,
+		Stdout: &out,
+	}}
+	if err := p.Resize(30, 100); !errors.Is(err, process.ErrNoTerminal) {
+		t.Errorf("invalid error before run: %v", err)
+	}
+	res := p.Run(context.TODO())
+	for !strings.Contains(out.String(), "\n") {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := p.Resize(30, 100); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second)
+	defer cancel()
+	in, w := io.Pipe()
+	defer w.Close()
+	go w.Write([]byte("\n"))
+	p.Attach(ctx, in, io.Discard)
+	<-res
+	if out.String() != "24 80\r\n30 100\r\n" {
+		t.Errorf("invalid output: %q", out.String())
+	}
+}
+
+func TestPTYRecord(t *testing.T) {
+	dir := t.TempDir()
+	var out syncBuffer
+	p := &process.Process{Spec: process.Spec{
+		Cmd:          "/bin/sh",
+		Args:         []string{"-c", "stty -echo; printf 'h\\303'; sleep 0.1; printf '\\251llo\\n'; read line"},
+		PTY:          true,
+		PTYRecordDir: dir,
+		Stdout:       &out,
+	}}
+	res := p.Run(context.TODO())
+	for !strings.Contains(out.String(), "\n") {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := p.Resize(30, 100); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second)
+	defer cancel()
+	in, w := io.Pipe()
+	defer w.Close()
+	go w.Write([]byte("bye\n"))
+	p.Attach(ctx, in, io.Discard)
+	<-res
+	files, _ := filepath.Glob(filepath.Join(dir, "*.cast"))
+	if len(files) != 1 {
+		t.Fatalf("invalid recordings: %v", files)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	var header struct{ Version, Width, Height int }
+	if err := json.Unmarshal([]byte(lines[0]), &header); err != nil || header.Version != 2 || header.Width != 80 || header.Height != 24 {
+		t.Errorf("invalid header: %s %v", lines[0], err)
+	}
+	var output, input, resize string
+	for _, line := range lines[1:] {
+		var e []interface{}
+		if err := json.Unmarshal([]byte(line), &e); err != nil || len(e) != 3 {
+			t.Fatalf("invalid event: %s %v", line, err)
+		}
+		switch e[1] {
+		case "o":
+			output += e[2].(string)
+		case "i":
+			input += e[2].(string)
+		case "r":
+			resize += e[2].(string)
+		}
+	}
+	if output != "héllo\r\n" || input != "bye\n" || resize != "100x30" {
+		t.Errorf("invalid events: %q %q %q", output, input, resize)
+	}
+}
@@ -0,0 +1,113 @@
+package process
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Audited operations
+const (
+	OpStart  = "start"
+	OpStop   = "stop"
+	OpApply  = "apply"
+	OpDrain  = "drain"
+	OpAttach = "attach"
+)
+
+const defaultAuditSize = 1000
+
+// AuditEntry records an operation requested on the supervisor
+type AuditEntry struct {
+	Time   time.Time `json:"time"`   // Time the operation completed
+	Op     string    `json:"op"`     // Operation, one of the Op constants
+	Name   string    `json:"name"`   // Process name, empty for operations on the whole supervisor
+	Source string    `json:"source"` // Identity of the requester passed to Supervisor.As, empty for direct calls
+	Err    string    `json:"err"`    // Error of the operation, empty if it succeeded
+}
+
+// AuditLog keeps the latest entries in memory and optionally writes every
+// entry to Writer as a JSON line. The zero value is ready to use.
+type AuditLog struct {
+	Size   int       // Number of entries kept in memory, 1000 if zero
+	Writer io.Writer // Destination of JSON lines, may be nil
+
+	mu      sync.Mutex
+	entries []AuditEntry
+}
+
+// Record adds the entry to the log
+func (l *AuditLog) Record(e AuditEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	size := l.Size
+	if size == 0 {
+		size = defaultAuditSize
+	}
+	if len(l.entries) >= size {
+		l.entries = append(l.entries[:0], l.entries[len(l.entries)-size+1:]...)
+	}
+	l.entries = append(l.entries, e)
+	if l.Writer != nil {
+		json.NewEncoder(l.Writer).Encode(e)
+	}
+}
+
+// Entries returns the kept entries accepted by match in order of recording,
+// all of them if match is nil
+func (l *AuditLog) Entries(match func(AuditEntry) bool) (res []AuditEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, e := range l.entries {
+		if match == nil || match(e) {
+			res = append(res, e)
+		}
+	}
+	return
+}
+
+// Operator requests supervisor operations on behalf of a source recorded in
+// the audit log, e.g. an API client
+type Operator struct {
+	s      *Supervisor
+	source string
+}
+
+// As returns an operator recording the source with every operation
+func (s *Supervisor) As(source string) Operator {
+	return Operator{s: s, source: source}
+}
+
+// Start starts the named process, see Supervisor.Start
+func (o Operator) Start(name string) error {
+	return o.s.audit(o.source, OpStart, name, o.s.start(name))
+}
+
+// Stop stops the named process, see Supervisor.Stop
+func (o Operator) Stop(name string) error {
+	return o.s.audit(o.source, OpStop, name, o.s.stop(name))
+}
+
+// Apply replaces the configuration, see Supervisor.Apply
+func (o Operator) Apply(specs map[string]Spec) error {
+	return o.s.audit(o.source, OpApply, "", o.s.apply(specs))
+}
+
+// Drain drains all the processes, see Supervisor.Drain
+func (o Operator) Drain() {
+	o.s.drain()
+	o.s.audit(o.source, OpDrain, "", nil)
+}
+
+// audit records the operation if Audit is set and returns its error
+func (s *Supervisor) audit(source, op, name string, err error) error {
+	if s.Audit != nil {
+		e := AuditEntry{Time: time.Now(), Op: op, Name: name, Source: source}
+		if err != nil {
+			e.Err = err.Error()
+		}
+		s.Audit.Record(e)
+	}
+	return err
+}
@@ -0,0 +1,63 @@
+package process_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andviro/process"
+)
+
+func TestAuditLog(t *testing.T) {
+	var (
+		out   syncBuffer
+		audit = &process.AuditLog{Size: 3, Writer: &out}
+		s     = process.Supervisor{Audit: audit}
+	)
+	manual := false
+	p := process.New("/bin/sleep", "5")
+	p.Autostart = &manual
+	s.Add("sleep", p)
+	if err := s.Start("sleep"); !errors.Is(err, process.ErrNotRunning) {
+		t.Fatalf("%+v", err)
+	}
+	ctx, cancel := context.WithCancel(context.TODO())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(ctx)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	api := s.As("client-1")
+	if err := api.Start("sleep"); err != nil {
+		t.Fatal(err)
+	}
+	if err := api.Stop("sleep"); err != nil {
+		t.Fatal(err)
+	}
+	api.Stop("missing")
+	cancel()
+	<-done
+
+	entries := audit.Entries(nil)
+	if len(entries) != 3 {
+		t.Fatalf("invalid entries: %+v", entries)
+	}
+	for i, op := range []string{process.OpStart, process.OpStop, process.OpStop} {
+		if e := entries[i]; e.Op != op || e.Source != "client-1" {
+			t.Errorf("invalid entry %d: %+v", i, e)
+		}
+	}
+	if e := entries[2]; e.Name != "missing" || e.Err == "" {
+		t.Errorf("invalid failed entry: %+v", e)
+	}
+	failed := audit.Entries(func(e process.AuditEntry) bool { return e.Err != "" })
+	if len(failed) != 1 {
+		t.Errorf("invalid filtered entries: %+v", failed)
+	}
+	if lines := strings.Count(out.String(), "\n"); lines != 4 {
+		t.Errorf("invalid written entries: %q", out.String())
+	}
+}
@@ -0,0 +1,96 @@
+package process
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// Scopes of control API credentials
+const (
+	ScopeRead    = "read"    // Status requests only, methods GET and HEAD
+	ScopeControl = "control" // All requests, including starting and stopping processes
+)
+
+// Credential grants a scope to a control API client
+type Credential struct {
+	Name  string `json:"name"`  // Identity of the client, recorded as audit source
+	Scope string `json:"scope"` // One of the Scope constants
+}
+
+// Auth authenticates requests to HTTP handlers such as ControlHandler.
+// Clients present a token with "Authorization: Bearer <token>", as basic
+// auth password with the credential name as user, or a TLS client
+// certificate verified by the server, see http.Server TLSConfig ClientAuth.
+type Auth struct {
+	Tokens  map[string]Credential `json:"tokens"`  // Credentials keyed by token
+	Clients map[string]Credential `json:"clients"` // Credentials keyed by common name of verified client certificates
+}
+
+type sourceKey struct{}
+
+// Handler returns the handler requiring authentication. Requests without
+// known credentials are rejected with status 401, requests beyond the
+// credential scope with 403. The handler can get the credential name with
+// RequestSource.
+func (a *Auth) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cred, ok := a.authenticate(r)
+		switch {
+		case !ok:
+			w.Header().Set("WWW-Authenticate", `Basic realm="process"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+		case !cred.allows(r.Method):
+			http.Error(w, "forbidden", http.StatusForbidden)
+		default:
+			h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), sourceKey{}, cred.Name)))
+		}
+	})
+}
+
+func (a *Auth) authenticate(r *http.Request) (Credential, bool) {
+	if r.TLS != nil && len(r.TLS.VerifiedChains) != 0 {
+		if cred, ok := a.Clients[r.TLS.VerifiedChains[0][0].Subject.CommonName]; ok {
+			return cred, true
+		}
+	}
+	if user, token, ok := r.BasicAuth(); ok {
+		cred, ok := a.token(token)
+		return cred, ok && cred.Name == user
+	}
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return a.token(token)
+	}
+	return Credential{}, false
+}
+
+// token looks the token up in constant time per stored token
+func (a *Auth) token(token string) (res Credential, ok bool) {
+	if token == "" {
+		return
+	}
+	for t, cred := range a.Tokens {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			res, ok = cred, true
+		}
+	}
+	return
+}
+
+func (c Credential) allows(method string) bool {
+	switch c.Scope {
+	case ScopeControl:
+		return true
+	case ScopeRead:
+		return method == http.MethodGet || method == http.MethodHead
+	}
+	return false
+}
+
+// RequestSource returns the name of the credential that authenticated the
+// request with Auth, empty if none did
+func RequestSource(r *http.Request) string {
+	name, _ := r.Context().Value(sourceKey{}).(string)
+	return name
+}
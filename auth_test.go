@@ -0,0 +1,44 @@
+package process_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andviro/process"
+)
+
+func TestAuth(t *testing.T) {
+	auth := &process.Auth{Tokens: map[string]process.Credential{
+		"ro": {Name: "dashboard", Scope: process.ScopeRead},
+		"rw": {Name: "ops", Scope: process.ScopeControl},
+	}}
+	var source string
+	h := auth.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		source = process.RequestSource(r)
+	}))
+	for _, tc := range []struct {
+		name, method string
+		set          func(*http.Request)
+		code         int
+		source       string
+	}{
+		{"none", "GET", func(*http.Request) {}, http.StatusUnauthorized, ""},
+		{"bad token", "GET", func(r *http.Request) { r.Header.Set("Authorization", "Bearer bad") }, http.StatusUnauthorized, ""},
+		{"empty token", "GET", func(r *http.Request) { r.Header.Set("Authorization", "Bearer ") }, http.StatusUnauthorized, ""},
+		{"read", "GET", func(r *http.Request) { r.Header.Set("Authorization", "Bearer ro") }, http.StatusOK, "dashboard"},
+		{"read post", "POST", func(r *http.Request) { r.Header.Set("Authorization", "Bearer ro") }, http.StatusForbidden, ""},
+		{"control post", "POST", func(r *http.Request) { r.Header.Set("Authorization", "Bearer rw") }, http.StatusOK, "ops"},
+		{"basic", "POST", func(r *http.Request) { r.SetBasicAuth("ops", "rw") }, http.StatusOK, "ops"},
+		{"basic wrong user", "GET", func(r *http.Request) { r.SetBasicAuth("dashboard", "rw") }, http.StatusUnauthorized, ""},
+	} {
+		source = ""
+		req := httptest.NewRequest(tc.method, "/", nil)
+		tc.set(req)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tc.code || source != tc.source {
+			t.Errorf("%s: invalid result: %d %q", tc.name, rec.Code, source)
+		}
+	}
+}
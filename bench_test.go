@@ -0,0 +1,38 @@
+package process
+
+import (
+	"io"
+	"testing"
+)
+
+func BenchmarkLog(b *testing.B) {
+	for _, level := range []string{LogInfo, LogError} {
+		b.Run(level, func(b *testing.B) {
+			p := &Process{Spec: Spec{Cmd: "/bin/true", Stderr: io.Discard, LogLevel: level}}
+			p.RunID = newRunID()
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				p.logf("finished with error: %v", ErrNotReady)
+			}
+		})
+	}
+}
+
+func BenchmarkLineWriter(b *testing.B) {
+	lines := []byte("2024-01-01 12:00:00 INFO request served in 12ms\n2024-01-01 12:00:00 INFO request\n")
+	for name, chunks := range map[string][][]byte{
+		"lines": {lines},
+		"split": {lines[:30], lines[30:70], lines[70:]},
+	} {
+		b.Run(name, func(b *testing.B) {
+			lw := &lineWriter{w: io.Discard, fn: func([]byte) {}}
+			b.ReportAllocs()
+			b.SetBytes(int64(len(lines)))
+			for i := 0; i < b.N; i++ {
+				for _, chunk := range chunks {
+					lw.Write(chunk)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+//go:build bolt
+
+package process
+
+import (
+	"encoding/json"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// boltBucket holds the records keyed by name
+var boltBucket = []byte("processes")
+
+// BoltStore keeps the records in a bbolt database. Unlike FileStore, a
+// record is written without rewriting the others, which suits supervisors
+// with many processes. It's only built with the "bolt" build tag, so that the
+// package doesn't depend on bbolt otherwise.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// OpenBoltStore opens or creates the database file. The file is locked while
+// the store is open, opening fails after a second if another supervisor
+// holds it.
+func OpenBoltStore(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &BoltStore{db: db}, nil
+}
+
+// Close releases the database file
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+// Load returns the record with the name
+func (s *BoltStore) Load(name string) (res Record, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(boltBucket).Get([]byte(name))
+		if data == nil {
+			return ErrNoRecord
+		}
+		return json.Unmarshal(data, &res)
+	})
+	return
+}
+
+// Save stores the record replacing existing one with the same name
+func (s *BoltStore) Save(r Record) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Put([]byte(r.Name), data)
+	})
+}
+
+// Delete removes the record with the name
+func (s *BoltStore) Delete(name string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltBucket)
+		if b.Get([]byte(name)) == nil {
+			return ErrNoRecord
+		}
+		return b.Delete([]byte(name))
+	})
+}
+
+// List returns all records ordered by name
+func (s *BoltStore) List() (res []Record, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).ForEach(func(k, v []byte) error {
+			var r Record
+			if err := json.Unmarshal(v, &r); err != nil {
+				return err
+			}
+			res = append(res, r)
+			return nil
+		})
+	})
+	return
+}
@@ -0,0 +1,53 @@
+package process
+
+import (
+	"io"
+	"sync"
+)
+
+// tailBuffer keeps the last size bytes written to it
+type tailBuffer struct {
+	mu   sync.Mutex
+	size int
+	buf  []byte
+}
+
+func newTailBuffer(size int) *tailBuffer {
+	return &tailBuffer{size: size}
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := len(p)
+	if n >= b.size {
+		b.buf = append(b.buf[:0], p[n-b.size:]...)
+		return n, nil
+	}
+	if over := len(b.buf) + n - b.size; over > 0 {
+		b.buf = b.buf[:copy(b.buf, b.buf[over:])]
+	}
+	b.buf = append(b.buf, p...)
+	return n, nil
+}
+
+// Bytes returns a copy of the buffer contents
+func (b *tailBuffer) Bytes() []byte {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]byte(nil), b.buf...)
+}
+
+// tee duplicates writes to w into extra, any of them may be nil
+func tee(w, extra io.Writer) io.Writer {
+	switch {
+	case w == nil:
+		return extra
+	case extra == nil:
+		return w
+	}
+	return io.MultiWriter(w, extra)
+}
@@ -0,0 +1,244 @@
+// Command processctl controls processes of processd over its control API.
+//
+//	processctl [-addr https://host:8443] [-token token] [-ca ca.pem] command [name]
+//
+// Commands are list, status, start, stop and attach. The token defaults to
+// PROCESSCTL_TOKEN environment variable. Attach connects the terminal in raw
+// mode to a process running with PTY, so that keys including ^C reach the
+// process, and keeps its window size in sync with the terminal; ^] detaches.
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/andviro/process"
+)
+
+// detachKey ends the attach session, ^]
+const detachKey = 0x1d
+
+type client struct {
+	base  *url.URL
+	token string
+	tls   *tls.Config
+	http  *http.Client
+}
+
+func main() {
+	var (
+		addr  = flag.String("addr", "http://localhost:8443", "control API `url`")
+		token = flag.String("token", os.Getenv("PROCESSCTL_TOKEN"), "control API `token`")
+		ca    = flag.String("ca", "", "CA `file` verifying the server certificate")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] list|status|start|stop|attach [name]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	log.SetPrefix("processctl: ")
+	log.SetFlags(0)
+
+	base, err := url.Parse(*addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	c := &client{base: base, token: *token, tls: &tls.Config{}}
+	if *ca != "" {
+		data, err := os.ReadFile(*ca)
+		if err != nil {
+			log.Fatal(err)
+		}
+		c.tls.RootCAs = x509.NewCertPool()
+		if !c.tls.RootCAs.AppendCertsFromPEM(data) {
+			log.Fatalf("%s: no certificates found", *ca)
+		}
+	}
+	c.http = &http.Client{Transport: &http.Transport{TLSClientConfig: c.tls}}
+
+	args := flag.Args()
+	if len(args) == 0 || args[0] != "list" && len(args) != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	switch args[0] {
+	case "list":
+		err = c.list()
+	case "status":
+		err = c.status(args[1])
+	case "start", "stop":
+		_, err = c.do(http.MethodPost, nil, "processes", args[1], args[0])
+	case "attach":
+		err = c.attach(args[1])
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// do sends the request to the path joined from the elements and returns the
+// body of a successful response, decoded into v if it's not nil
+func (c *client) do(method string, v interface{}, elem ...string) ([]byte, error) {
+	return c.doQuery(method, v, nil, elem...)
+}
+
+func (c *client) doQuery(method string, v interface{}, query url.Values, elem ...string) ([]byte, error) {
+	u := c.base.JoinPath(elem...)
+	u.RawQuery = query.Encode()
+	req, err := http.NewRequest(method, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	if v != nil {
+		return body, json.Unmarshal(body, v)
+	}
+	return body, nil
+}
+
+func (c *client) list() error {
+	var list []process.ProcessInfo
+	if _, err := c.do(http.MethodGet, &list, "processes"); err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSTATE\tPID\tUPTIME\tRESTARTS\tLAST ERROR")
+	for _, p := range list {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%d\t%s\n", p.Name, p.State, p.Pid, p.Uptime.Round(time.Second), p.RestartCount, p.LastError)
+	}
+	return w.Flush()
+}
+
+func (c *client) status(name string) error {
+	body, err := c.do(http.MethodGet, nil, "processes", name)
+	if err == nil {
+		_, err = os.Stdout.Write(body)
+	}
+	return err
+}
+
+// attach upgrades the connection to AttachProtocol and bridges it with the
+// terminal
+func (c *client) attach(name string) error {
+	host := c.base.Host
+	if c.base.Port() == "" {
+		host = net.JoinHostPort(c.base.Hostname(), map[bool]string{true: "443", false: "80"}[c.base.Scheme == "https"])
+	}
+	var (
+		conn net.Conn
+		err  error
+	)
+	if c.base.Scheme == "https" {
+		conf := c.tls.Clone()
+		conf.ServerName = c.base.Hostname()
+		conn, err = tls.Dial("tcp", host, conf)
+	} else {
+		conn, err = net.Dial("tcp", host)
+	}
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	req, err := http.NewRequest(http.MethodPost, c.base.JoinPath("processes", name, "attach").String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", process.AttachProtocol)
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if err = req.Write(conn); err != nil {
+		return err
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	if restore, err := makeRaw(os.Stdin); err == nil {
+		defer restore()
+	}
+	// The window size is passed with separate requests on every change
+	stop := watchSize(os.Stdin, func(rows, cols int) {
+		query := url.Values{"rows": {strconv.Itoa(rows)}, "cols": {strconv.Itoa(cols)}}
+		if _, err := c.doQuery(http.MethodPost, nil, query, "processes", name, "resize"); err != nil {
+			fmt.Fprintf(os.Stderr, "resize: %v\r\n", err)
+		}
+	})
+	defer stop()
+	fmt.Fprintf(os.Stderr, "attached to %s, ^] detaches\r\n", name)
+	done := make(chan error, 2)
+	go func() {
+		_, err := io.Copy(os.Stdout, br)
+		done <- err
+	}()
+	go func() {
+		done <- copyInput(conn, os.Stdin)
+	}()
+	if err = <-done; errors.Is(err, errDetached) {
+		err = nil
+	}
+	fmt.Fprint(os.Stderr, "\r\ndetached\r\n")
+	return err
+}
+
+var errDetached = errors.New("detached")
+
+// copyInput passes the terminal input to the connection until detachKey
+func copyInput(w io.Writer, r io.Reader) error {
+	buf := make([]byte, 1024)
+	for {
+		n, err := r.Read(buf)
+		if i := strings.IndexByte(string(buf[:n]), detachKey); i >= 0 {
+			w.Write(buf[:i])
+			return errDetached
+		}
+		if n > 0 {
+			if _, err := w.Write(buf[:n]); err != nil {
+				return err
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
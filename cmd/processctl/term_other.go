@@ -0,0 +1,18 @@
+//go:build !linux
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+// makeRaw is not supported, the terminal stays in cooked mode
+func makeRaw(f *os.File) (restore func(), err error) {
+	return nil, errors.New("raw mode is not supported on this platform")
+}
+
+// watchSize is not supported, the window size is left unchanged
+func watchSize(f *os.File, fn func(rows, cols int)) (stop func()) {
+	return func() {}
+}
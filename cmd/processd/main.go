@@ -0,0 +1,157 @@
+// Command processd runs the processes of a configuration file under a
+// supervisor, reloading the file when it changes.
+//
+//	processd -config processes.json [-check] [-debug localhost:6060]
+//		[-listen :8443 -auth auth.json [-read-only]
+//		[-tls-cert cert.pem -tls-key key.pem [-client-ca ca.pem]]]
+//
+// With -check the configuration is validated and the start plan is printed
+// without starting anything. With -debug pprof profiles and runtime stats of
+// the supervisor itself are served on the address, see
+// process.Supervisor.DebugHandler. With -listen the control API is served on
+// the address under /processes, see process.Supervisor.ControlHandler, with
+// the web dashboard at the root and health under /health. The API requires credentials from the auth file,
+// process.Auth as JSON:
+//
+//	{
+//		"tokens": {"3f9a...": {"name": "ops", "scope": "control"}},
+//		"clients": {"dashboard.example.com": {"name": "dashboard", "scope": "read"}}
+//	}
+//
+// With -tls-cert and -tls-key the API is served over TLS, with -client-ca
+// clients must also present certificates signed by the CA, their common
+// names are looked up in "clients". With -read-only the API rejects
+// starting and stopping processes regardless of the credential scopes.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/andviro/process"
+)
+
+func main() {
+	var (
+		config = flag.String("config", "processd.json", "configuration `file`")
+		check  = flag.Bool("check", false, "validate the configuration, print the start plan and exit")
+		debug  = flag.String("debug", "", "serve pprof and runtime stats on the `address`, e.g. localhost:6060")
+		listen = flag.String("listen", "", "serve the control API on the `address`")
+		creds  = flag.String("auth", "", "control API credentials `file`, required with -listen")
+		cert   = flag.String("tls-cert", "", "control API TLS certificate `file`")
+		key    = flag.String("tls-key", "", "control API TLS key `file`")
+		ca     = flag.String("client-ca", "", "CA `file` verifying control API client certificates")
+		ro     = flag.Bool("read-only", false, "only serve status requests of the control API")
+	)
+	flag.Parse()
+	log.SetPrefix("processd: ")
+	log.SetFlags(0)
+
+	s := &process.Supervisor{Defaults: process.Spec{Stdout: os.Stdout, Stderr: os.Stderr}}
+	if *check {
+		os.Exit(checkConfig(*config, s.Defaults))
+	}
+	var auth process.Auth
+	control := &http.Server{Addr: *listen}
+	if *listen != "" {
+		if *creds == "" {
+			log.Fatal("-listen requires -auth")
+		}
+		if err := loadJSON(*creds, &auth); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *cert != "" || *key != "" || *ca != "" {
+		var err error
+		if control.TLSConfig, err = process.TLSConfig(*cert, *key, *ca); err != nil {
+			log.Fatal(err)
+		}
+	}
+	specs, err := process.LoadConfig(*config, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = s.Apply(specs); err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	if *debug != "" {
+		srv := &http.Server{Addr: *debug, Handler: s.DebugHandler()}
+		go serve(srv)
+		defer srv.Close()
+	}
+	if *listen != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/", s.DashboardHandler())
+		mux.Handle("/health", s.HealthHandler())
+		var h http.Handler = mux
+		if *ro {
+			h = process.ReadOnly(mux)
+		}
+		control.Handler = auth.Handler(h)
+		go serve(control)
+		defer control.Close()
+	}
+	go s.WatchConfig(ctx, process.ConfigWatch{
+		Paths:   []string{*config},
+		Load:    func() (map[string]process.Spec, error) { return process.LoadConfig(*config, nil) },
+		OnError: func(err error) { log.Print(err) },
+	})
+	for name, res := range s.Run(ctx) {
+		if res.Err != nil && res.Reason != process.ReasonCanceled {
+			log.Printf("%s: %s: %v", name, res.Reason, res.Err)
+		}
+	}
+}
+
+// checkConfig prints the start plan of the configuration file as JSON and
+// returns the exit code
+func checkConfig(path string, defaults process.Spec) int {
+	f, err := os.Open(path)
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+	defer f.Close()
+	plan, err := process.ValidateConfig(f, nil, defaults)
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(plan)
+	return 0
+}
+
+func serve(srv *http.Server) {
+	var err error
+	if srv.TLSConfig != nil {
+		err = srv.ListenAndServeTLS("", "")
+	} else {
+		err = srv.ListenAndServe()
+	}
+	if err != nil && err != http.ErrServerClosed {
+		log.Print(err)
+	}
+}
+
+func loadJSON(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package process
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const redacted = "xxxxx"
+
+// secretWords mark flag and variable names whose values are always redacted
+var secretWords = []string{"pass", "secret", "token", "key", "auth", "credential"}
+
+// Command lookup modes
+const (
+	LookupPath = "path" // Search PATH for Cmd without path separators
+	LookupDir  = "dir"  // Resolve Cmd without path separators in Dir
+	LookupNone = "none" // Require Cmd to be a path, absolute or relative to Dir
+)
+
+// cmdPath resolves the command according to CmdLookup. Paths with
+// separators are left as is, relative ones are evaluated relative to Dir
+// when the child is launched.
+func (s Spec) cmdPath(cmd string) (string, error) {
+	if strings.ContainsAny(cmd, "/"+string(os.PathSeparator)) {
+		return cmd, nil
+	}
+	switch s.CmdLookup {
+	case LookupDir:
+		return "." + string(os.PathSeparator) + cmd, nil
+	case LookupNone:
+		return "", fmt.Errorf("%w: %q", ErrNoPathLookup, cmd)
+	}
+	return cmd, nil
+}
+
+// expandArgFiles replaces "@file" arguments with non-empty lines of the file
+// if ArgFiles is set. Lines starting with # are comments, relative paths are
+// resolved in Dir. Argument files are not nested, the lines are passed as is.
+func (s Spec) expandArgFiles(args []string) ([]string, error) {
+	if !s.ArgFiles {
+		return args, nil
+	}
+	res := make([]string, 0, len(args))
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "@@"):
+			res = append(res, arg[1:])
+		case strings.HasPrefix(arg, "@") && len(arg) > 1:
+			lines, err := readArgFile(arg[1:], s.Dir)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, lines...)
+		default:
+			res = append(res, arg)
+		}
+	}
+	return res, nil
+}
+
+func readArgFile(name, dir string) (res []string, err error) {
+	if !filepath.IsAbs(name) && dir != "" {
+		name = filepath.Join(dir, name)
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("argument file: %w", err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		res = append(res, line)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("argument file %s: %w", name, err)
+	}
+	return
+}
+
+// DisplayCommand renders the command line quoted for a POSIX shell. Values of
+// flags and assignments with secret-looking names or matching RedactArgs, and
+// passwords in URLs are redacted.
+func (s Spec) DisplayCommand() string {
+	return s.displayCommand(s.Cmd, s.Args)
+}
+
+// String returns the redacted command line
+func (s Spec) String() string {
+	return s.DisplayCommand()
+}
+
+func (s Spec) displayCommand(cmd string, args []string) string {
+	parts := make([]string, 0, len(args)+1)
+	parts = append(parts, shellQuote(cmd))
+	hideNext := false
+	for _, arg := range args {
+		switch {
+		case hideNext && !strings.HasPrefix(arg, "-"):
+			arg = redacted
+			hideNext = false
+		case strings.Contains(arg, "="):
+			hideNext = false
+			i := strings.IndexByte(arg, '=')
+			if s.secret(arg[:i]) {
+				arg = arg[:i+1] + redacted
+			} else if u, err := url.Parse(arg[i+1:]); err == nil && u.User != nil {
+				arg = arg[:i+1] + u.Redacted()
+			}
+		case strings.HasPrefix(arg, "-"):
+			hideNext = s.secret(arg)
+		default:
+			hideNext = false
+			if u, err := url.Parse(arg); err == nil && u.User != nil {
+				arg = u.Redacted()
+			}
+		}
+		parts = append(parts, shellQuote(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+// secret reports whether the flag or variable name denotes a secret value
+func (s Spec) secret(name string) bool {
+	name = strings.ToLower(strings.TrimLeft(name, "-"))
+	if name == "" {
+		return false
+	}
+	for _, w := range secretWords {
+		if strings.Contains(name, w) {
+			return true
+		}
+	}
+	for _, pattern := range s.RedactArgs {
+		if ok, _ := path.Match(strings.ToLower(pattern), name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// shellQuote quotes the word for a POSIX shell if it contains special
+// characters
+func shellQuote(word string) string {
+	if word == "" {
+		return "''"
+	}
+	if strings.IndexFunc(word, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=@%+,", r))
+	}) < 0 {
+		return word
+	}
+	return "'" + strings.ReplaceAll(word, "'", `'\''`) + "'"
+}
@@ -0,0 +1,265 @@
+package process
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+)
+
+// ConfigWatch describes configuration files reloaded by Supervisor.WatchConfig
+type ConfigWatch struct {
+	Paths   []string                        // Glob patterns of the configuration files
+	Delay   int                             // Time in ms the files must stay unchanged before reload, 500 if zero
+	Load    func() (map[string]Spec, error) // Parses the files into specs keyed by process name, e.g. with LoadConfig
+	OnError func(error)                     // Receives load and apply errors, may be nil
+}
+
+// Apply replaces the supervisor configuration with the specs keyed by process
+// name. Defaults are applied and every spec is validated first, so that an
+// invalid configuration leaves the supervisor unchanged. Processes missing
+// from the specs are stopped and removed, changed ones are stopped and
+// replaced with new processes, new ones are added. While the supervisor is
+// running, added and replaced processes with autostart are started. Specs
+// are compared after defaults are applied, including unexported state and
+// writers; specs with non-nil function fields never compare equal, so such
+// processes are replaced on every Apply.
+func (s *Supervisor) Apply(specs map[string]Spec) error {
+	return s.audit("", OpApply, "", s.apply(specs))
+}
+
+func (s *Supervisor) apply(specs map[string]Spec) error {
+	s.mu.Lock()
+	names, resolved, err := resolveSpecs(specs, s.Defaults)
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	if s.procs == nil {
+		s.procs = make(map[string]*Process)
+	}
+	var stale []string
+	kept := s.names[:0:0]
+	for _, name := range s.names {
+		if _, ok := resolved[name]; ok {
+			kept = append(kept, name)
+			continue
+		}
+		stale = append(stale, name)
+		delete(s.procs, name)
+	}
+	var fresh []*supervised
+	for _, name := range names {
+		spec := resolved[name]
+		p, ok := s.procs[name]
+		switch {
+		case !ok:
+			kept = append(kept, name)
+		case sameSpec(p.Spec, spec):
+			continue
+		default:
+			stale = append(stale, name)
+		}
+		p = &Process{Spec: spec}
+		s.procs[name] = p
+		fresh = append(fresh, &supervised{name: name, p: p})
+	}
+	s.names = kept
+	run := s.run
+	if run != nil {
+		defer run.hold()()
+	}
+	s.mu.Unlock()
+
+	if run == nil {
+		return nil
+	}
+	for _, name := range stale {
+		if m := run.last(name); m != nil {
+			m.cancel()
+			<-m.done
+		}
+	}
+	for _, g := range byPriority(fresh) {
+		for _, m := range g {
+			if m.p.enabled() && m.p.autostart() {
+				run.start(m)
+			}
+		}
+	}
+	return nil
+}
+
+// WatchConfig polls the configuration files every Delay for changes of size
+// and modification time, no file system notifications are used, and applies
+// the specs returned by Load once changes settle down, until the context is
+// cancelled. The
+// initial configuration is not loaded, see Apply. If Load or Apply fails the
+// previous configuration stays in effect and the error is passed to OnError.
+// Reloads are audited with "config" source.
+func (s *Supervisor) WatchConfig(ctx context.Context, w ConfigWatch) {
+	pollChanges(w.Paths, w.Delay, ctx.Done(), func(string) {
+		specs, err := w.Load()
+		if err == nil {
+			err = s.As("config").Apply(specs)
+		}
+		if err != nil && w.OnError != nil {
+			w.OnError(err)
+		}
+	})
+}
+
+// PlannedProcess describes a process of a validated configuration
+type PlannedProcess struct {
+	Name      string `json:"name"`      // Process name
+	Command   string `json:"command"`   // Command line with secrets redacted, see Spec.DisplayCommand
+	Dir       string `json:"dir"`       // Working directory
+	Priority  int    `json:"priority"`  // Start priority
+	Enabled   bool   `json:"enabled"`   // Process can be started
+	Autostart bool   `json:"autostart"` // Process is started with the supervisor
+}
+
+// ParseConfig decodes a JSON configuration file, an object with optional
+// "defaults" spec and "processes" specs keyed by name:
+//
+//	{
+//		"defaults": {"stopTimeout": "10s"},
+//		"processes": {
+//			"web": {"preset": "http", "args": ["--port", "8080"]},
+//			"worker": {"cmd": "/usr/bin/worker"}
+//		}
+//	}
+//
+// Fields of a process with "preset" are decoded on top of the template
+// registered in presets; presets may be nil if none are used. The "defaults"
+// are applied with Spec.WithDefaults. Decoding errors are prefixed with the
+// process name.
+func ParseConfig(r io.Reader, presets *Presets) (map[string]Spec, error) {
+	var file struct {
+		Defaults  Spec                       `json:"defaults"`
+		Processes map[string]json.RawMessage `json:"processes"`
+	}
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	specs := make(map[string]Spec, len(file.Processes))
+	var errs []error
+	for name, raw := range file.Processes {
+		spec, err := parseProcess(raw, presets)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		specs[name] = spec.WithDefaults(file.Defaults)
+	}
+	if len(errs) != 0 {
+		sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+		return nil, errors.Join(errs...)
+	}
+	return specs, nil
+}
+
+func parseProcess(raw json.RawMessage, presets *Presets) (spec Spec, err error) {
+	var ref struct {
+		Preset string `json:"preset"`
+	}
+	if err = json.Unmarshal(raw, &ref); err != nil {
+		return
+	}
+	if ref.Preset != "" {
+		if presets == nil {
+			return spec, fmt.Errorf("%w: %q", ErrUnknownPreset, ref.Preset)
+		}
+		if spec, err = presets.Spec(ref.Preset); err != nil {
+			return
+		}
+	}
+	err = json.Unmarshal(raw, &spec)
+	return
+}
+
+// LoadConfig reads the configuration file, see ParseConfig. It can serve as
+// ConfigWatch.Load.
+func LoadConfig(path string, presets *Presets) (map[string]Spec, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseConfig(f, presets)
+}
+
+// ApplyConfig parses the configuration and applies it, see ParseConfig and
+// Apply
+func (s *Supervisor) ApplyConfig(r io.Reader, presets *Presets) error {
+	specs, err := ParseConfig(r, presets)
+	if err != nil {
+		return s.audit("", OpApply, "", err)
+	}
+	return s.Apply(specs)
+}
+
+// ValidateConfig parses the configuration, applies the supervisor defaults
+// and validates the specs like Supervisor.ApplyConfig without starting
+// anything. The processes are returned in the order the supervisor would
+// start them.
+func ValidateConfig(r io.Reader, presets *Presets, defaults Spec) ([]PlannedProcess, error) {
+	specs, err := ParseConfig(r, presets)
+	if err != nil {
+		return nil, err
+	}
+	names, resolved, err := resolveSpecs(specs, defaults)
+	if err != nil {
+		return nil, err
+	}
+	members := make([]*supervised, len(names))
+	for i, name := range names {
+		members[i] = &supervised{name: name, p: &Process{Spec: resolved[name]}}
+	}
+	var res []PlannedProcess
+	for _, g := range byPriority(members) {
+		for _, m := range g {
+			res = append(res, PlannedProcess{
+				Name:      m.name,
+				Command:   m.p.DisplayCommand(),
+				Dir:       m.p.Dir,
+				Priority:  m.p.Priority,
+				Enabled:   m.p.enabled(),
+				Autostart: m.p.autostart(),
+			})
+		}
+	}
+	return res, nil
+}
+
+// resolveSpecs applies the defaults and validates the specs, returning
+// their names in order
+func resolveSpecs(specs map[string]Spec, defaults Spec) ([]string, map[string]Spec, error) {
+	names := make([]string, 0, len(specs))
+	for name := range specs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	resolved := make(map[string]Spec, len(specs))
+	var errs []error
+	for _, name := range names {
+		spec := specs[name].WithDefaults(defaults)
+		spec.Name = name
+		if err := spec.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+		resolved[name] = spec
+	}
+	if len(errs) != 0 {
+		return nil, nil, errors.Join(errs...)
+	}
+	return names, resolved, nil
+}
+
+func sameSpec(a, b Spec) bool {
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,181 @@
+package process_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andviro/process"
+)
+
+func TestSupervisorApply(t *testing.T) {
+	s := process.Supervisor{Defaults: process.Spec{StartTimeout: 10, StopTimeout: 1000}}
+	sleep := func(arg string) process.Spec { return process.Spec{Cmd: "/bin/sleep", Args: []string{arg}} }
+	if err := s.Apply(map[string]process.Spec{"a": sleep("5"), "b": sleep("5")}); err != nil {
+		t.Fatal(err)
+	}
+	oldA := s.Get("a")
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	done := make(chan map[string]process.RunResult)
+	go func() { done <- s.Run(ctx) }()
+	time.Sleep(100 * time.Millisecond)
+
+	err := s.Apply(map[string]process.Spec{"a": {Args: []string{"6"}}})
+	var verr *process.ValidationError
+	if !errors.As(err, &verr) || !strings.HasPrefix(err.Error(), "a: ") {
+		t.Errorf("invalid error: %v", err)
+	}
+	if names := s.Names(); len(names) != 2 || s.Get("a") != oldA {
+		t.Fatalf("invalid config applied: %v", names)
+	}
+	if err := s.Apply(map[string]process.Spec{"a": sleep("6"), "c": sleep("5")}); err != nil {
+		t.Fatal(err)
+	}
+	if names := s.Names(); len(names) != 2 || names[0] != "a" || names[1] != "c" {
+		t.Errorf("invalid names: %v", names)
+	}
+	if oldA.Status().State != process.StateStopped {
+		t.Errorf("replaced process is %s", oldA.Status().State)
+	}
+	time.Sleep(100 * time.Millisecond)
+	for _, name := range []string{"a", "c"} {
+		if st := s.Get(name).Status(); st.State != process.StateRunning {
+			t.Errorf("%s is %s", name, st.State)
+		}
+	}
+	cancel()
+	res := <-done
+	if len(res) != 3 {
+		t.Errorf("invalid results: %+v", res)
+	}
+}
+
+func TestSupervisorApplyChanges(t *testing.T) {
+	var s process.Supervisor
+	specs := map[string]process.Spec{"a": {Cmd: "/bin/sleep", Args: []string{"5"}}}
+	if err := s.Apply(specs); err != nil {
+		t.Fatal(err)
+	}
+	p := s.Get("a")
+	s.Apply(specs)
+	if s.Get("a") != p {
+		t.Error("unchanged process replaced")
+	}
+	s.Defaults.Env = []string{"LOG=debug"}
+	s.Apply(specs)
+	if s.Get("a") == p {
+		t.Error("process with changed inherited environment kept")
+	}
+	p = s.Get("a")
+	specs["a"] = process.Spec{Cmd: "/bin/sleep", Args: []string{"5"}, EnvFunc: func(int) []string { return nil }}
+	s.Apply(specs)
+	if s.Get("a") == p {
+		t.Error("process with changed function field kept")
+	}
+}
+
+func TestSupervisorWatchConfig(t *testing.T) {
+	conf := filepath.Join(t.TempDir(), "procs.json")
+	write := func(specs map[string]process.Spec) {
+		data, _ := json.Marshal(specs)
+		if err := os.WriteFile(conf, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	load := func() (res map[string]process.Spec, err error) {
+		data, err := os.ReadFile(conf)
+		if err == nil {
+			err = json.Unmarshal(data, &res)
+		}
+		return
+	}
+	write(map[string]process.Spec{"a": {Cmd: "/bin/sleep", Args: []string{"5"}}})
+	var s process.Supervisor
+	specs, _ := load()
+	if err := s.Apply(specs); err != nil {
+		t.Fatal(err)
+	}
+	errs := make(chan error, 10)
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	go s.WatchConfig(ctx, process.ConfigWatch{
+		Paths:   []string{conf},
+		Delay:   20,
+		Load:    load,
+		OnError: func(err error) { errs <- err },
+	})
+	time.Sleep(50 * time.Millisecond)
+	write(map[string]process.Spec{"b": {Cmd: "/bin/sleep", Args: []string{"5"}}})
+	time.Sleep(200 * time.Millisecond)
+	if names := s.Names(); len(names) != 1 || names[0] != "b" {
+		t.Errorf("config not applied: %v", names)
+	}
+	os.WriteFile(conf, []byte("{"), 0644)
+	select {
+	case <-errs:
+	case <-time.After(time.Second):
+		t.Error("load error not reported")
+	}
+	if names := s.Names(); len(names) != 1 || names[0] != "b" {
+		t.Errorf("config not kept: %v", names)
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	var presets process.Presets
+	presets.Add("server", process.Spec{Cmd: "/usr/bin/web", Priority: 2, StopTimeout: 5000})
+	config := `{
+		"defaults": {"stopTimeout": "1s"},
+		"processes": {
+			"web": {"preset": "server", "args": ["--token", "s3cret"]},
+			"db":  {"cmd": "/usr/bin/db", "dir": "/var/lib/db", "priority": 1},
+			"job": {"cmd": "/usr/bin/job", "priority": 2, "autostart": false}
+		}
+	}`
+	plan, err := process.ValidateConfig(strings.NewReader(config), &presets, process.Spec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan) != 3 || plan[0].Name != "db" || plan[1].Name != "job" || plan[2].Name != "web" {
+		t.Fatalf("invalid plan: %+v", plan)
+	}
+	if plan[0].Dir != "/var/lib/db" || plan[1].Autostart || !plan[2].Autostart {
+		t.Errorf("invalid plan: %+v", plan)
+	}
+	if plan[2].Command != "/usr/bin/web --token xxxxx" {
+		t.Errorf("invalid command: %q", plan[2].Command)
+	}
+	for _, tc := range []struct {
+		config string
+		prefix string
+	}{
+		{`{"processes": {`, "config: "},
+		{`{"processes": {"a": {"preset": "missing"}}}`, "a: unknown preset"},
+		{`{"processes": {"a": {"cmd": "/bin/true", "stopTimeout": "soon"}}}`, "a: stopTimeout: "},
+		{`{"processes": {"a": {"cmd": "/bin/true", "stopTimeout": -1}}}`, "a: invalid process spec"},
+	} {
+		_, err := process.ValidateConfig(strings.NewReader(tc.config), &presets, process.Spec{})
+		if err == nil || !strings.HasPrefix(err.Error(), tc.prefix) {
+			t.Errorf("%s: invalid error: %v", tc.config, err)
+		}
+	}
+}
+
+func TestSupervisorApplyConfig(t *testing.T) {
+	var s process.Supervisor
+	if err := s.ApplyConfig(strings.NewReader(`{"processes": {"a": {"cmd": "/bin/true"}}}`), nil); err != nil {
+		t.Fatal(err)
+	}
+	if names := s.Names(); len(names) != 1 || names[0] != "a" {
+		t.Errorf("config not applied: %v", names)
+	}
+	if err := s.ApplyConfig(strings.NewReader(`{"processes": {"b": {"preset": "x"}}}`), nil); !errors.Is(err, process.ErrUnknownPreset) {
+		t.Errorf("invalid error: %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package process
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+var consoleColors = []int{36, 33, 32, 35, 34, 31, 96, 93, 92, 95, 94, 91}
+
+// Console multiplexes line output of several processes onto one writer,
+// prefixing every line with the aligned process name, foreman-style
+type Console struct {
+	Color bool // Colorize names, enabled by NewConsole for terminals unless NO_COLOR is set
+
+	mu     sync.Mutex
+	w      io.Writer
+	width  int
+	colors map[string]int
+}
+
+// NewConsole creates console writing to w
+func NewConsole(w io.Writer) *Console {
+	_, noColor := os.LookupEnv("NO_COLOR")
+	return &Console{
+		Color:  !noColor && isTerminal(w),
+		w:      w,
+		colors: make(map[string]int),
+	}
+}
+
+// Writer returns a writer for the named process. Names registered so far
+// determine alignment, so it's best to create all writers before starting
+// the processes. The writer is safe for concurrent use, so it can be shared by
+// the child stderr and package messages.
+func (c *Console) Writer(name string) io.Writer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.colors[name]; !ok {
+		c.colors[name] = consoleColors[len(c.colors)%len(consoleColors)]
+	}
+	if len(name) > c.width {
+		c.width = len(name)
+	}
+	return &lineWriter{fn: func(line []byte) {
+		c.line(name, line)
+	}}
+}
+
+func (c *Console) line(name string, line []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.Color {
+		fmt.Fprintf(c.w, "\x1b[%dm%-*s |\x1b[0m %s\n", c.colors[name], c.width, name, line)
+		return
+	}
+	fmt.Fprintf(c.w, "%-*s | %s\n", c.width, name, line)
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,63 @@
+package process_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andviro/process"
+)
+
+func TestConsole(t *testing.T) {
+	var buf syncBuffer
+	c := process.NewConsole(&buf)
+	if c.Color {
+		t.Error("color enabled for non-terminal")
+	}
+	web, worker := c.Writer("web"), c.Writer("worker")
+	fmt.Fprint(web, "listening\nready")
+	fmt.Fprint(worker, "started\n")
+	fmt.Fprint(web, "\n")
+	expected := "web    | listening\nworker | started\nweb    | ready\n"
+	if out := buf.String(); out != expected {
+		t.Errorf("invalid output: %q", out)
+	}
+}
+
+func TestConsoleColor(t *testing.T) {
+	var buf syncBuffer
+	c := process.NewConsole(&buf)
+	c.Color = true
+	p := &process.Process{Spec: process.Spec{
+		Cmd:  "/bin/sh",
+		Args: []string{"-c", "echo hello"},
+	}}
+	p.Stdout = c.Writer("sh")
+	<-p.Run(context.TODO())
+	if out := buf.String(); !strings.HasPrefix(out, "\x1b[36msh |\x1b[0m hello\n") {
+		t.Errorf("invalid output: %q", out)
+	}
+}
+
+func TestConsoleSharedStderr(t *testing.T) {
+	var buf syncBuffer
+	c := process.NewConsole(&buf)
+	spec := process.Spec{
+		Cmd:          "/bin/sh",
+		Args:         []string{"-c", "while :; do printf 'par' >&2; printf 'tial\\n' >&2; done"},
+		Stderr:       c.Writer("app"),
+		LogLevel:     process.LogDebug,
+		StartTimeout: 50,
+		StopTimeout:  1000,
+	}
+	ctx, cancel := context.WithTimeout(context.TODO(), 200*time.Millisecond)
+	defer cancel()
+	spec.Run(ctx).Wait()
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		if !strings.HasPrefix(line, "app | ") || strings.Contains(line[6:], "app | ") {
+			t.Fatalf("corrupted line: %q", line)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package process
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ProcessInfo summarizes a supervised process for the control API
+type ProcessInfo struct {
+	Name         string        `json:"name"`         // Process name within the supervisor
+	State        State         `json:"state"`        // Current state
+	Pid          int           `json:"pid"`          // Pid of the running child, 0 if there's none
+	Uptime       time.Duration `json:"uptime"`       // Total time spent running
+	RestartCount int           `json:"restartCount"` // Current number of runs
+	LastError    string        `json:"lastError"`    // Last error encountered, empty if none
+}
+
+// Processes returns the summary of every process in order of addition
+func (s *Supervisor) Processes() []ProcessInfo {
+	names := s.Names()
+	res := make([]ProcessInfo, 0, len(names))
+	for _, name := range names {
+		if p := s.Get(name); p != nil {
+			res = append(res, processInfo(name, p))
+		}
+	}
+	return res
+}
+
+func processInfo(name string, p *Process) ProcessInfo {
+	st := p.Status()
+	res := ProcessInfo{
+		Name:         name,
+		State:        st.State,
+		Pid:          st.Pid,
+		Uptime:       st.Uptime,
+		RestartCount: st.RestartCount,
+	}
+	if st.LastError != nil {
+		res.LastError = st.LastError.Error()
+	}
+	return res
+}
+
+// ControlHandler returns HTTP handler of the control API:
+//
+//	GET  /processes               list of ProcessInfo
+//	GET  /processes/{name}        ProcessInfo of the process
+//	GET  /processes/{name}/output output tail kept with CaptureOutput as text
+//	GET  /processes/{name}/events stream of Event as JSON lines until the run finishes
+//	POST /processes/{name}/start  start the process, see Supervisor.Start
+//	POST /processes/{name}/stop   stop the process, see Supervisor.Stop
+//	POST /processes/{name}/attach interactive session with the PTY child, see AttachProtocol
+//	POST /processes/{name}/resize set the window size of the PTY child to ?rows=R&cols=C
+//
+// Responses are JSON, operations reply with status 204 and are audited with
+// the source set by Auth. The handler controls production processes and
+// should be wrapped with Auth.Handler if reachable by untrusted clients, or
+// with ReadOnly to only expose the status.
+func (s *Supervisor) ControlHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/processes" && !strings.HasPrefix(r.URL.Path, "/processes/") {
+			http.NotFound(w, r)
+			return
+		}
+		name, op, _ := strings.Cut(strings.Trim(r.URL.Path[len("/processes"):], "/"), "/")
+		switch {
+		case op == "" && r.Method != http.MethodGet && r.Method != http.MethodHead:
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		case name == "":
+			writeJSON(w, s.Processes())
+		case r.Method == http.MethodGet || r.Method == http.MethodHead:
+			p := s.Get(name)
+			switch {
+			case p == nil:
+				http.NotFound(w, r)
+			case op == "":
+				writeJSON(w, processInfo(name, p))
+			case op == "output":
+				w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+				w.Write(p.Output())
+			case op == "events":
+				writeEvents(w, r, p)
+			default:
+				w.Header().Set("Allow", "POST")
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			}
+		case r.Method != http.MethodPost:
+			w.Header().Set("Allow", "POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		case op == "start":
+			writeResult(w, s.As(RequestSource(r)).Start(name))
+		case op == "stop":
+			writeResult(w, s.As(RequestSource(r)).Stop(name))
+		case op == "attach":
+			s.serveAttach(w, r, name)
+		case op == "resize":
+			s.serveResize(w, r, name)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// ReadOnly returns the handler rejecting requests other than GET and HEAD
+// with status 405, e.g. to expose ControlHandler to dashboards without
+// control of the processes
+func ReadOnly(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "read-only", http.StatusMethodNotAllowed)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// eventInfo is Event with the error as text
+type eventInfo struct {
+	Event
+	Err string `json:"err"`
+}
+
+// writeEvents streams events of the process until the subscription is
+// closed or the client goes away
+func writeEvents(w http.ResponseWriter, r *http.Request, p *Process) {
+	events, cancel := p.Subscribe(nil)
+	defer cancel()
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	if flusher != nil {
+		flusher.Flush()
+	}
+	enc := json.NewEncoder(w)
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			info := eventInfo{Event: e}
+			if e.Err != nil {
+				info.Err = e.Err.Error()
+			}
+			if enc.Encode(info) != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// writeResult replies to an operation with its error mapped to the status
+func writeResult(w http.ResponseWriter, err error) {
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, ErrNoProcess):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrNotRunning), errors.Is(err, ErrAlreadyRunning), errors.Is(err, ErrDisabled), errors.Is(err, ErrNoTerminal):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// AttachProtocol is the Upgrade header value of attach requests to
+// ControlHandler. After the 101 response the connection carries the raw
+// input and output of the PTY child until either side closes it, see
+// Process.Attach. Attaching is audited with OpAttach.
+const AttachProtocol = "process-attach"
+
+func (s *Supervisor) serveAttach(w http.ResponseWriter, r *http.Request, name string) {
+	p := s.Get(name)
+	switch {
+	case p == nil:
+		http.NotFound(w, r)
+		return
+	case !strings.EqualFold(r.Header.Get("Upgrade"), AttachProtocol):
+		w.Header().Set("Upgrade", AttachProtocol)
+		http.Error(w, "upgrade required", http.StatusUpgradeRequired)
+		return
+	case p.terminal() == nil:
+		writeResult(w, s.audit(RequestSource(r), OpAttach, name, ErrNoTerminal))
+		return
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "connection can't be hijacked", http.StatusInternalServerError)
+		return
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer conn.Close()
+	s.audit(RequestSource(r), OpAttach, name, nil)
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: " + AttachProtocol + "\r\n\r\n")
+	if rw.Flush() == nil {
+		p.Attach(context.Background(), rw.Reader, conn)
+	}
+}
+
+func (s *Supervisor) serveResize(w http.ResponseWriter, r *http.Request, name string) {
+	rows, err := strconv.Atoi(r.URL.Query().Get("rows"))
+	cols, cerr := strconv.Atoi(r.URL.Query().Get("cols"))
+	if err != nil || cerr != nil || rows <= 0 || cols <= 0 || rows > 0xffff || cols > 0xffff {
+		http.Error(w, "invalid window size", http.StatusBadRequest)
+		return
+	}
+	p := s.Get(name)
+	if p == nil {
+		http.NotFound(w, r)
+		return
+	}
+	writeResult(w, p.Resize(rows, cols))
+}
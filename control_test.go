@@ -0,0 +1,149 @@
+package process_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/andviro/process"
+)
+
+func TestControlHandler(t *testing.T) {
+	s := process.Supervisor{Audit: &process.AuditLog{}}
+	manual := false
+	s.Add("sleep", &process.Process{Spec: process.Spec{
+		Cmd:         "sleep",
+		Args:        []string{"10"},
+		Autostart:   &manual,
+		StopTimeout: 1000,
+	}})
+	auth := &process.Auth{Tokens: map[string]process.Credential{
+		"secret": {Name: "ops", Scope: process.ScopeControl},
+	}}
+	srv := httptest.NewServer(auth.Handler(s.ControlHandler()))
+	defer srv.Close()
+	ctx, cancel := context.WithCancel(context.TODO())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	do := func(method, path string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(method, srv.URL+path, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	for _, tc := range []struct {
+		method, path string
+		code         int
+	}{
+		{"POST", "/processes/sleep/stop", http.StatusConflict},
+		{"POST", "/processes/sleep/start", http.StatusNoContent},
+		{"GET", "/processes/sleep/start", http.StatusMethodNotAllowed},
+		{"POST", "/processes/missing/start", http.StatusNotFound},
+		{"DELETE", "/processes/sleep", http.StatusMethodNotAllowed},
+		{"GET", "/processesx", http.StatusNotFound},
+	} {
+		resp := do(tc.method, tc.path)
+		resp.Body.Close()
+		if resp.StatusCode != tc.code {
+			t.Errorf("%s %s: invalid status %d", tc.method, tc.path, resp.StatusCode)
+		}
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	resp := do("GET", "/processes/sleep")
+	var info process.ProcessInfo
+	err := json.NewDecoder(resp.Body).Decode(&info)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Name != "sleep" || info.State != process.StateRunning || info.Pid == 0 {
+		t.Errorf("invalid info: %+v", info)
+	}
+	resp = do("GET", "/processes")
+	var list []process.ProcessInfo
+	err = json.NewDecoder(resp.Body).Decode(&list)
+	resp.Body.Close()
+	if err != nil || len(list) != 1 || list[0].Name != "sleep" {
+		t.Errorf("invalid list: %+v %v", list, err)
+	}
+	resp = do("POST", "/processes/sleep/stop")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("invalid stop status: %d", resp.StatusCode)
+	}
+	entries := s.Audit.Entries(func(e process.AuditEntry) bool { return e.Err == "" })
+	if len(entries) != 2 || entries[0].Source != "ops" || entries[1].Op != process.OpStop {
+		t.Errorf("invalid audit: %+v", entries)
+	}
+}
+
+func TestControlReadOnly(t *testing.T) {
+	var s process.Supervisor
+	s.Add("echo", &process.Process{Spec: process.Spec{
+		Cmd:           "/bin/sh",
+		Args:          []string{"-c", "echo hello; sleep 0.3"},
+		CaptureOutput: 100,
+	}})
+	srv := httptest.NewServer(process.ReadOnly(s.ControlHandler()))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/processes/echo/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(context.TODO())
+	}()
+	dec := json.NewDecoder(resp.Body)
+	var states []process.State
+	for {
+		var e struct{ State process.State }
+		if err := dec.Decode(&e); err != nil {
+			break
+		}
+		states = append(states, e.State)
+	}
+	<-done
+	if len(states) < 2 || states[0] != process.StateStarting || states[len(states)-1] != process.StateStopped {
+		t.Errorf("invalid events: %v", states)
+	}
+
+	resp, err = http.Get(srv.URL + "/processes/echo/output")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "hello\n" {
+		t.Errorf("invalid output: %q", body)
+	}
+
+	resp, err = http.Post(srv.URL+"/processes/echo/start", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("invalid status: %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,77 @@
+package process
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// CrashInfo describes artifacts collected after the child exited with error
+type CrashInfo struct {
+	RunID  string    `json:"runId"`  // Identifier of the crashed start attempt
+	Pid    int       `json:"pid"`    // Pid of the crashed child
+	Time   time.Time `json:"time"`   // Time of the crash
+	Err    error     `json:"-"`      // Exit error
+	Signal string    `json:"signal"` // Signal which killed the child, empty for error exits
+	Dir    string    `json:"dir"`    // Directory with collected artifacts, empty if CrashDir is not set
+	Core   string    `json:"core"`   // Path to the core file, empty if none was found
+	Output []byte    `json:"output"` // Tail of the output captured with CaptureOutput
+}
+
+// collectCrash gathers the core file and the output tail of the crashed
+// child into CrashDir and passes them to OnCrash
+func (p *Process) collectCrash(err error) {
+	info := CrashInfo{
+		RunID:  p.RunID,
+		Pid:    p.cmd.Process.Pid,
+		Time:   time.Now(),
+		Err:    err,
+		Output: p.capture.Bytes(),
+	}
+	if ws, ok := p.cmd.ProcessState.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		info.Signal = ws.Signal().String()
+	}
+	info.Core = p.findCore(info.Pid)
+	if p.CrashDir != "" {
+		dir := filepath.Join(p.CrashDir, p.RunID)
+		if err := os.MkdirAll(dir, p.dirMode()); err != nil {
+			p.warnf("crash artifacts: %v", err)
+		} else {
+			info.Dir = dir
+			if len(info.Output) > 0 {
+				if err := os.WriteFile(filepath.Join(dir, "output.log"), info.Output, 0644); err != nil {
+					p.warnf("crash artifacts: %v", err)
+				}
+			}
+			if info.Core != "" {
+				dst := filepath.Join(dir, filepath.Base(info.Core))
+				if err := os.Rename(info.Core, dst); err != nil {
+					p.warnf("crash artifacts: %v", err)
+				} else {
+					info.Core = dst
+				}
+			}
+		}
+	}
+	if p.OnCrash != nil {
+		p.OnCrash(info)
+	}
+}
+
+// findCore looks for a core file written to the child working directory
+// after the start with the default core_pattern
+func (p *Process) findCore(pid int) string {
+	dir := p.cmd.Dir
+	if dir == "" {
+		dir = "."
+	}
+	for _, name := range []string{"core." + strconv.Itoa(pid), "core"} {
+		path := filepath.Join(dir, name)
+		if fi, err := os.Stat(path); err == nil && fi.Mode().IsRegular() && !fi.ModTime().Before(p.started.Truncate(time.Second)) {
+			return path
+		}
+	}
+	return ""
+}
@@ -0,0 +1,27 @@
+package process
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// allowCore raises the soft core size limit of the child to its hard limit.
+// The limit is changed right after the start, a crash before that is not
+// dumped.
+func allowCore(pid int) error {
+	var lim syscall.Rlimit
+	if err := prlimit(pid, syscall.RLIMIT_CORE, nil, &lim); err != nil {
+		return err
+	}
+	lim.Cur = lim.Max
+	return prlimit(pid, syscall.RLIMIT_CORE, &lim, nil)
+}
+
+func prlimit(pid, resource int, lim, old *syscall.Rlimit) error {
+	_, _, errno := syscall.RawSyscall6(syscall.SYS_PRLIMIT64, uintptr(pid), uintptr(resource),
+		uintptr(unsafe.Pointer(lim)), uintptr(unsafe.Pointer(old)), 0, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
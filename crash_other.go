@@ -0,0 +1,8 @@
+//go:build !linux
+
+package process
+
+// allowCore is a no-op, the child inherits the core limit of the supervisor
+func allowCore(pid int) error {
+	return nil
+}
@@ -0,0 +1,37 @@
+package process_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/andviro/process"
+)
+
+func TestCrashArtifacts(t *testing.T) {
+	dir := t.TempDir()
+	var info process.CrashInfo
+	p := &process.Process{Spec: process.Spec{
+		Cmd:           "/bin/sh",
+		Args:          []string{"-c", "echo last words; kill -SEGV $$"},
+		StartTimeout:  1000,
+		CaptureOutput: 1024,
+		CoreDump:      true,
+		CrashDir:      dir,
+		OnCrash:       func(ci process.CrashInfo) { info = ci },
+	}}
+	res := <-p.Run(context.TODO())
+	if info.RunID != res.RunID || info.Err == nil || info.Pid == 0 {
+		t.Fatalf("invalid crash info: %+v", info)
+	}
+	if info.Signal != "segmentation fault" {
+		t.Errorf("invalid signal: %q", info.Signal)
+	}
+	if info.Dir != filepath.Join(dir, res.RunID) {
+		t.Errorf("invalid artifact dir: %q", info.Dir)
+	}
+	if data, err := os.ReadFile(filepath.Join(info.Dir, "output.log")); err != nil || string(data) != "last words\n" {
+		t.Errorf("invalid output tail: %q %v", data, err)
+	}
+}
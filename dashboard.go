@@ -0,0 +1,31 @@
+package process
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+//go:embed dashboard.html
+var dashboardPage []byte
+
+// DashboardHandler returns HTTP handler serving a web page at the root that
+// lists the processes with their state, uptime, restart count and output
+// tail of the selected one, with buttons to start and stop them. The page
+// uses ControlHandler served by the handler under /processes. Wrap the
+// handler with Auth.Handler to gate the buttons by credential scope, the
+// browser asks for the credential name and token as basic auth.
+func (s *Supervisor) DashboardHandler() http.Handler {
+	control := s.ControlHandler()
+	mux := http.NewServeMux()
+	mux.Handle("/processes", control)
+	mux.Handle("/processes/", control)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(dashboardPage)
+	})
+	return mux
+}
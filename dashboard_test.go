@@ -0,0 +1,41 @@
+package process_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andviro/process"
+)
+
+func TestDashboardHandler(t *testing.T) {
+	var s process.Supervisor
+	s.Add("true", process.New("/bin/true"))
+	srv := httptest.NewServer(s.DashboardHandler())
+	defer srv.Close()
+
+	for path, want := range map[string]string{
+		"/":          "<title>Processes</title>",
+		"/processes": `"name":"true"`,
+	} {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), want) {
+			t.Errorf("%s: invalid response: %d %q", path, resp.StatusCode, body)
+		}
+	}
+	resp, err := http.Get(srv.URL + "/missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("invalid status: %d", resp.StatusCode)
+	}
+}
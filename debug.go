@@ -0,0 +1,58 @@
+package process
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	rpprof "runtime/pprof"
+)
+
+// RuntimeStats is a snapshot of the supervisor's own runtime
+type RuntimeStats struct {
+	Goroutines int    `json:"goroutines"` // Number of goroutines
+	Threads    int    `json:"threads"`    // Number of OS threads created
+	HeapAlloc  uint64 `json:"heapAlloc"`  // Bytes of allocated heap objects
+	HeapInuse  uint64 `json:"heapInuse"`  // Bytes in in-use heap spans
+	NumGC      uint32 `json:"numGC"`      // Number of completed GC cycles
+	Processes  int    `json:"processes"`  // Number of processes in the supervisor
+	Running    int    `json:"running"`    // Number of processes started and not finished
+}
+
+// RuntimeStats returns the snapshot of the supervisor runtime
+func (s *Supervisor) RuntimeStats() RuntimeStats {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	res := RuntimeStats{
+		Goroutines: runtime.NumGoroutine(),
+		Threads:    rpprof.Lookup("threadcreate").Count(),
+		HeapAlloc:  m.HeapAlloc,
+		HeapInuse:  m.HeapInuse,
+		NumGC:      m.NumGC,
+	}
+	for _, name := range s.Names() {
+		res.Processes++
+		if s.Get(name).IsRunning() {
+			res.Running++
+		}
+	}
+	return res
+}
+
+// DebugHandler returns HTTP handler serving pprof profiles under
+// /debug/pprof/ and RuntimeStats as JSON under /debug/runtime, for
+// diagnosing leaks of the supervisor itself. It exposes internals and should
+// not be reachable from untrusted networks.
+func (s *Supervisor) DebugHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/debug/runtime", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.RuntimeStats())
+	})
+	return mux
+}
@@ -0,0 +1,39 @@
+package process_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andviro/process"
+)
+
+func TestDebugHandler(t *testing.T) {
+	var s process.Supervisor
+	s.Add("true", process.New("/bin/true"))
+	srv := httptest.NewServer(s.DebugHandler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/debug/runtime")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var stats process.RuntimeStats
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Goroutines == 0 || stats.Threads == 0 || stats.Processes != 1 || stats.Running != 0 {
+		t.Errorf("invalid stats: %+v", stats)
+	}
+
+	resp, err = http.Get(srv.URL + "/debug/pprof/goroutine?debug=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("invalid status: %d", resp.StatusCode)
+	}
+}
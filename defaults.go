@@ -0,0 +1,105 @@
+package process
+
+// WithDefaults returns a copy of the spec with zero fields taken from the
+// defaults. Timeouts, restart settings, environment filters, output
+// destinations, limits, the start limiter and metrics are inherited. Env of
+// the defaults is prepended to the spec Env, so that the spec values win, or
+// added to the filtered parent environment if the spec Env is nil.
+//
+// A zero value set in Go can't be told from an unset one and is replaced,
+// e.g. MaxRestarts: 0 inherits the default restart limit. Fields present in
+// the JSON the spec was decoded from are kept even if zero.
+func (s Spec) WithDefaults(d Spec) Spec {
+	s = s.Clone()
+	ints := []struct {
+		name     string
+		dst, src *int
+	}{
+		{"startTimeout", &s.StartTimeout, &d.StartTimeout},
+		{"backoffTimeout", &s.BackoffTimeout, &d.BackoffTimeout},
+		{"stopTimeout", &s.StopTimeout, &d.StopTimeout},
+		{"killTimeout", &s.KillTimeout, &d.KillTimeout},
+		{"restartTimeout", &s.RestartTimeout, &d.RestartTimeout},
+		{"maxStartAttempts", &s.MaxStartAttempts, &d.MaxStartAttempts},
+		{"maxRestarts", &s.MaxRestarts, &d.MaxRestarts},
+		{"captureOutput", &s.CaptureOutput, &d.CaptureOutput},
+		{"outputBuffer", &s.OutputBuffer, &d.OutputBuffer},
+	}
+	for _, f := range ints {
+		if *f.dst == 0 && !s.explicit(f.name) {
+			*f.dst = *f.src
+		}
+	}
+	if s.StdoutLimit == 0 && !s.explicit("stdoutLimit") {
+		s.StdoutLimit = d.StdoutLimit
+	}
+	if s.StderrLimit == 0 && !s.explicit("stderrLimit") {
+		s.StderrLimit = d.StderrLimit
+	}
+	if s.Jitter == 0 && !s.explicit("jitter") {
+		s.Jitter = d.Jitter
+	}
+	strs := []struct {
+		name     string
+		dst, src *string
+	}{
+		{"logLevel", &s.LogLevel, &d.LogLevel},
+		{"outputLimitAction", &s.OutputLimitAction, &d.OutputLimitAction},
+		{"outputOverflow", &s.OutputOverflow, &d.OutputOverflow},
+		{"restartPolicy", &s.RestartPolicy, &d.RestartPolicy},
+	}
+	for _, f := range strs {
+		if *f.dst == "" && !s.explicit(f.name) {
+			*f.dst = *f.src
+		}
+	}
+	switch {
+	case d.Env == nil:
+	case s.Env != nil:
+		s.Env = append(copyStrings(d.Env), s.Env...)
+	default:
+		// Keep the parent environment and add the defaults on top of it
+		s.inheritedEnv = append(copyStrings(d.Env), s.inheritedEnv...)
+	}
+	if s.PassEnv == nil {
+		s.PassEnv = copyStrings(d.PassEnv)
+	}
+	if s.BlockEnv == nil {
+		s.BlockEnv = copyStrings(d.BlockEnv)
+	}
+	if s.Stdout == nil {
+		s.Stdout = d.Stdout
+	}
+	if s.Stderr == nil {
+		s.Stderr = d.Stderr
+	}
+	if s.Sink == nil {
+		s.Sink = d.Sink
+	}
+	if s.StartLimiter == nil {
+		s.StartLimiter = d.StartLimiter
+	}
+	if s.Metrics == nil {
+		s.Metrics = d.Metrics
+	}
+	if s.StdoutPolicy == nil && d.StdoutPolicy != nil && !s.explicit("stdoutPolicy") {
+		v := *d.StdoutPolicy
+		s.StdoutPolicy = &v
+	}
+	if s.StderrPolicy == nil && d.StderrPolicy != nil && !s.explicit("stderrPolicy") {
+		v := *d.StderrPolicy
+		s.StderrPolicy = &v
+	}
+	if s.LineFormat == nil && d.LineFormat != nil && !s.explicit("lineFormat") {
+		f := *d.LineFormat
+		s.LineFormat = &f
+	}
+	return s
+}
+
+// explicit reports whether the field with the JSON name was present when the
+// spec was decoded
+func (s Spec) explicit(name string) bool {
+	_, ok := s.setFields[name]
+	return ok
+}
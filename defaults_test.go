@@ -0,0 +1,86 @@
+package process_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/andviro/process"
+)
+
+func TestWithDefaults(t *testing.T) {
+	var stdout syncBuffer
+	d := process.Spec{
+		StopTimeout:   5000,
+		RestartPolicy: "always",
+		Env:           []string{"A=1", "B=1"},
+		Stdout:        &stdout,
+	}
+	s := process.Spec{
+		Cmd:         "/bin/true",
+		StopTimeout: 100,
+		Env:         []string{"B=2"},
+	}
+	res := s.WithDefaults(d)
+	if res.StopTimeout != 100 || res.RestartPolicy != "always" || res.Stdout != &stdout {
+		t.Errorf("invalid spec: %+v", res)
+	}
+	if len(res.Env) != 3 || res.Env[0] != "A=1" || res.Env[2] != "B=2" {
+		t.Errorf("invalid env: %v", res.Env)
+	}
+	if len(s.Env) != 1 {
+		t.Errorf("source modified: %v", s.Env)
+	}
+
+	sv := process.Supervisor{Defaults: d}
+	sv.Add("true", process.New("/bin/true"))
+	if p := sv.Get("true"); p.RestartPolicy != "always" || p.StopTimeout == 5000 {
+		t.Errorf("invalid supervised spec: %+v", p.Spec)
+	}
+}
+
+func TestWithDefaultsExplicitZero(t *testing.T) {
+	d := process.Spec{StartTimeout: 1000, MaxRestarts: -1, Jitter: 0.2, RestartPolicy: "always"}
+	var s process.Spec
+	if err := json.Unmarshal([]byte(`{"cmd": "/bin/true", "startTimeout": 0, "maxRestarts": 0, "jitter": 0}`), &s); err != nil {
+		t.Fatal(err)
+	}
+	res := s.WithDefaults(d)
+	if res.StartTimeout != 0 || res.MaxRestarts != 0 || res.Jitter != 0 || res.RestartPolicy != "always" {
+		t.Errorf("explicit zero replaced: %+v", res)
+	}
+	// Zero values set in Go are indistinguishable from unset ones
+	res = process.Spec{Cmd: "/bin/true", MaxRestarts: 0}.WithDefaults(d)
+	if res.StartTimeout != 1000 || res.MaxRestarts != -1 {
+		t.Errorf("defaults not applied: %+v", res)
+	}
+}
+
+func TestWithDefaultsParentEnv(t *testing.T) {
+	os.Setenv("PROCESS_TEST_PARENT", "1")
+	defer os.Unsetenv("PROCESS_TEST_PARENT")
+	var stdout syncBuffer
+	s := process.Supervisor{Defaults: process.Spec{Env: []string{"LOG=debug"}}}
+	p := &process.Process{Spec: process.Spec{Cmd: "/usr/bin/env", Stdout: &stdout}}
+	if err := s.Add("env", p); err != nil {
+		t.Fatal(err)
+	}
+	s.Run(context.TODO())
+	vars := strings.Fields(stdout.String())
+	for _, kv := range []string{"PROCESS_TEST_PARENT=1", "LOG=debug"} {
+		if !contains(vars, kv) {
+			t.Errorf("%s not passed: %v", kv, vars)
+		}
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
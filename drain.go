@@ -0,0 +1,85 @@
+package process
+
+import (
+	"gopkg.in/andviro/go-state.v2"
+
+	"context"
+	"time"
+)
+
+const drainProbeInterval = 100 * time.Millisecond
+
+// Drain stops the process gracefully: restarts are no longer made, the child
+// receives DrainSignal and is stopped once DrainProbe reports that in-flight
+// work is finished, or after DrainTimeout. The call does not wait for the
+// process to stop.
+func (p *Process) Drain() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.active {
+		return ErrNotRunning
+	}
+	if !p.drained {
+		p.drained = true
+		close(p.drain)
+	}
+	return nil
+}
+
+// Drain drains all the processes
+func (s *Supervisor) Drain() {
+	s.drain()
+	s.audit("", OpDrain, "", nil)
+}
+
+func (s *Supervisor) drain() {
+	for _, name := range s.Names() {
+		s.Get(name).Drain()
+	}
+}
+
+func (p *Process) isDraining() bool {
+	select {
+	case <-p.drain:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *Process) draining(c context.Context) (res state.Func) {
+	if p.DrainSignal != "" {
+		sig, err := parseSignal(p.DrainSignal)
+		if err == nil {
+			err = p.cmd.Process.Signal(sig)
+		}
+		if err != nil {
+			p.warnf("drain signal: %v", err)
+		}
+	}
+	var probe <-chan time.Time
+	if p.DrainProbe != nil {
+		t := time.NewTicker(drainProbeInterval)
+		defer t.Stop()
+		probe = t.C
+	}
+	timeout := p.after(p.DrainTimeout)
+	for {
+		select {
+		case <-c.Done():
+			return p.stopping
+		case p.LastError = <-p.result:
+			p.logf("finished with error: %v", p.LastError)
+			p.exited(p.LastError)
+			return p.stopped
+		case <-probe:
+			if p.DrainProbe() {
+				p.debugf("drained")
+				return p.stopping
+			}
+		case <-timeout:
+			p.warnf("drain timeout exceeded")
+			return p.stopping
+		}
+	}
+}
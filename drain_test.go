@@ -0,0 +1,60 @@
+package process_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andviro/process"
+)
+
+func TestDrain(t *testing.T) {
+	if err := new(process.Process).Drain(); !errors.Is(err, process.ErrNotRunning) {
+		t.Errorf("%+v", err)
+	}
+	done := filepath.Join(t.TempDir(), "done")
+	var stdout syncBuffer
+	var s process.Supervisor
+	s.Add("worker", &process.Process{Spec: process.Spec{
+		Cmd:           "/bin/sh",
+		Args:          []string{"-c", "trap 'echo draining; sleep 0.2; touch " + done + "' TERM; while true; do sleep 0.01; done"},
+		Stdout:        &stdout,
+		StartTimeout:  50,
+		StopTimeout:   1000,
+		RestartPolicy: "always",
+		DrainSignal:   "SIGTERM",
+		DrainTimeout:  2000,
+		DrainProbe: func() bool {
+			_, err := os.Stat(done)
+			return err == nil
+		},
+	}})
+	p := s.Get("worker")
+	events, _ := p.Subscribe(nil)
+	start := time.Now()
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		s.Drain()
+	}()
+	res := s.Run(context.TODO())["worker"]
+	var seen []string
+	for e := range events {
+		seen = append(seen, e.State.String())
+	}
+	if strings.Join(seen, " ") != "starting running draining stopping stopped" {
+		t.Errorf("invalid states: %v", seen)
+	}
+	if res.Restarts != 0 || res.State != process.StateStopped {
+		t.Errorf("invalid result: %+v", res)
+	}
+	if !strings.Contains(stdout.String(), "draining") {
+		t.Errorf("drain signal not received: %q", stdout.String())
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("drain took %v", elapsed)
+	}
+}
@@ -0,0 +1,130 @@
+package process
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// specDurations are JSON names of Spec fields holding milliseconds
+var specDurations = []string{
+	"startTimeout", "backoffTimeout", "stopTimeout", "killTimeout",
+	"restartTimeout", "watchChildren", "drainTimeout", "watchDelay",
+}
+
+// UnmarshalJSON decodes the spec accepting duration strings such as "500ms"
+// or "2m" as well as integer milliseconds for the timeouts and intervals.
+// Fields present in the data are remembered, so that WithDefaults keeps
+// their values even if they are zero.
+func (s *Spec) UnmarshalJSON(data []byte) error {
+	data, err := durationsToMillis(data, specDurations...)
+	if err != nil {
+		return err
+	}
+	type plain Spec
+	if err = json.Unmarshal(data, (*plain)(s)); err != nil {
+		return err
+	}
+	var fields map[string]json.RawMessage
+	json.Unmarshal(data, &fields)
+	// Fields of a spec decoded on top of another one, e.g. a preset, add up
+	set := make(map[string]struct{}, len(s.setFields)+len(fields))
+	for name := range s.setFields {
+		set[name] = struct{}{}
+	}
+	for name := range fields {
+		set[name] = struct{}{}
+	}
+	s.setFields = set
+	return nil
+}
+
+// UnmarshalJSON decodes the spec like Spec.UnmarshalJSON, which would be
+// promoted otherwise, and the run-time parameters. LastError has no JSON form
+// and is not decoded.
+func (p *Process) UnmarshalJSON(data []byte) error {
+	if err := p.Spec.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	rt := struct {
+		StartAttempt *int    `json:"startAttempt"`
+		RestartCount *int    `json:"restartCount"`
+		State        *State  `json:"state"`
+		RunID        *string `json:"runId"`
+	}{&p.StartAttempt, &p.RestartCount, &p.State, &p.RunID}
+	return json.Unmarshal(data, &rt)
+}
+
+// UnmarshalJSON decodes the step accepting a duration string for Wait
+func (s *StopStep) UnmarshalJSON(data []byte) error {
+	data, err := durationsToMillis(data, "wait")
+	if err != nil {
+		return err
+	}
+	type plain StopStep
+	return json.Unmarshal(data, (*plain)(s))
+}
+
+// UnmarshalJSON decodes the threshold accepting a duration string for Window
+func (t *CPUThreshold) UnmarshalJSON(data []byte) error {
+	data, err := durationsToMillis(data, "window")
+	if err != nil {
+		return err
+	}
+	type plain CPUThreshold
+	return json.Unmarshal(data, (*plain)(t))
+}
+
+// UnmarshalJSON decodes the threshold accepting a duration string for
+// Interval
+func (t *FDThreshold) UnmarshalJSON(data []byte) error {
+	data, err := durationsToMillis(data, "interval")
+	if err != nil {
+		return err
+	}
+	type plain FDThreshold
+	return json.Unmarshal(data, (*plain)(t))
+}
+
+// UnmarshalJSON decodes the profiler accepting duration strings for
+// Interval and Timeout
+func (pr *Profiler) UnmarshalJSON(data []byte) error {
+	data, err := durationsToMillis(data, "interval", "timeout")
+	if err != nil {
+		return err
+	}
+	type plain Profiler
+	return json.Unmarshal(data, (*plain)(pr))
+}
+
+// durationsToMillis replaces duration strings of the named fields of the
+// JSON object with integer milliseconds
+func durationsToMillis(data []byte, names ...string) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		// Let the caller report a value that is not an object
+		return data, nil
+	}
+	changed := false
+	for _, name := range names {
+		raw, ok := fields[name]
+		if !ok || len(raw) == 0 || raw[0] != '"' {
+			continue
+		}
+		var str string
+		if err := json.Unmarshal(raw, &str); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		d, err := time.ParseDuration(str)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		fields[name] = json.RawMessage(strconv.FormatInt(d.Milliseconds(), 10))
+		changed = true
+	}
+	if !changed {
+		return data, nil
+	}
+	return json.Marshal(fields)
+}
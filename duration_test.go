@@ -0,0 +1,51 @@
+package process_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/andviro/process"
+)
+
+func TestSpecDurations(t *testing.T) {
+	var s process.Spec
+	data := `{
+		"cmd": "/bin/true",
+		"startTimeout": "1.5s",
+		"stopTimeout": 2000,
+		"restartTimeout": "2m",
+		"stopSequence": [{"signal": "SIGTERM", "wait": "250ms"}],
+		"profiler": {"cmd": "jstack", "interval": "1m", "timeout": 5000}
+	}`
+	if err := json.Unmarshal([]byte(data), &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Cmd != "/bin/true" || s.StartTimeout != 1500 || s.StopTimeout != 2000 || s.RestartTimeout != 120000 {
+		t.Errorf("invalid spec: %+v", s)
+	}
+	if s.StopSequence[0].Wait != 250 || s.Profiler.Interval != 60000 || s.Profiler.Timeout != 5000 {
+		t.Errorf("invalid nested durations: %+v %+v", s.StopSequence, s.Profiler)
+	}
+	if err := json.Unmarshal([]byte(`{"killTimeout": "soon"}`), &s); err == nil {
+		t.Error("invalid duration accepted")
+	}
+	out, _ := json.Marshal(s)
+	var back process.Spec
+	if err := json.Unmarshal(out, &back); err != nil || back.StartTimeout != 1500 {
+		t.Errorf("round trip failed: %v %+v", err, back)
+	}
+}
+
+func TestProcessUnmarshal(t *testing.T) {
+	var p process.Process
+	data := `{"cmd": "/bin/true", "stopTimeout": "2s", "state": "running", "restartCount": 3, "runId": "abc"}`
+	if err := json.Unmarshal([]byte(data), &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Cmd != "/bin/true" || p.StopTimeout != 2000 {
+		t.Errorf("invalid spec: %+v", p.Spec)
+	}
+	if p.State != process.StateRunning || p.RestartCount != 3 || p.RunID != "abc" {
+		t.Errorf("run-time parameters not decoded: %s %d %q", p.State, p.RestartCount, p.RunID)
+	}
+}
@@ -0,0 +1,30 @@
+package process
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+)
+
+// Privilege elevation tools
+const (
+	ElevateSudo = "sudo"
+	ElevateDoas = "doas"
+)
+
+// elevate returns the command line running the child through the elevation
+// tool. The tool is first checked to work without a password prompt, since
+// there is nobody to answer it.
+func (p *Process) elevate(path string, args []string) (cmd string, res []string, err error) {
+	if !canElevate {
+		return "", nil, fmt.Errorf("%w: not supported on this platform", ErrElevation)
+	}
+	check := exec.Command(p.Elevate, "-n", "true")
+	if out, err := check.CombinedOutput(); err != nil {
+		if out = bytes.TrimSpace(out); len(out) > 0 {
+			return "", nil, fmt.Errorf("%w: %s: %s", ErrElevation, err, out)
+		}
+		return "", nil, fmt.Errorf("%w: %s", ErrElevation, err)
+	}
+	return p.Elevate, append([]string{"-n", "--", path}, args...), nil
+}
@@ -0,0 +1,53 @@
+package process_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/andviro/process"
+)
+
+func fakeSudo(t *testing.T, script string) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "sudo"), []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := os.Getenv("PATH")
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+path)
+	t.Cleanup(func() { os.Setenv("PATH", path) })
+}
+
+func TestElevate(t *testing.T) {
+	fakeSudo(t, `[ "$1" = -n ] || exit 2; shift; [ "$1" = -- ] && shift; ELEVATED=1 exec "$@"`)
+	var stdout syncBuffer
+	p := &process.Process{Spec: process.Spec{
+		Cmd:          "/bin/sh",
+		Args:         []string{"-c", "echo $ELEVATED"},
+		Elevate:      process.ElevateSudo,
+		Stdout:       &stdout,
+		StartTimeout: 1000,
+	}}
+	res := <-p.Run(context.TODO())
+	if res.Err != nil {
+		t.Fatalf("%+v", res.Err)
+	}
+	if out := stdout.String(); out != "1\n" {
+		t.Errorf("invalid output: %q", out)
+	}
+}
+
+func TestElevatePassword(t *testing.T) {
+	fakeSudo(t, "echo 'sudo: a password is required' >&2; exit 1")
+	p := &process.Process{Spec: process.Spec{
+		Cmd:          "/bin/true",
+		Elevate:      process.ElevateSudo,
+		StartTimeout: 1000,
+	}}
+	res := <-p.Run(context.TODO())
+	if res.State != process.StateFailed || !errors.Is(res.Err, process.ErrElevation) {
+		t.Errorf("%v %+v", res.State, res.Err)
+	}
+}
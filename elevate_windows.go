@@ -0,0 +1,5 @@
+package process
+
+// Windows has no non-interactive counterpart of sudo: runas always prompts
+// for the password
+const canElevate = false
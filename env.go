@@ -0,0 +1,113 @@
+package process
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"path"
+	"strings"
+)
+
+// RunIDEnv is the name of environment variable holding the start attempt identifier
+const RunIDEnv = "PROCESS_RUN_ID"
+
+func newRunID() string {
+	var buf [8]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(buf[:])
+}
+
+// environ composes the child environment for the current start attempt
+func (p *Process) environ(ctx context.Context) (res []string) {
+	res = p.baseEnv()
+	res = append(res[:len(res):len(res)], RunIDEnv+"="+p.RunID)
+	if p.EnvFunc != nil {
+		res = append(res, p.EnvFunc(p.starts)...)
+	}
+	if p.ContextEnv != nil {
+		res = append(res, p.ContextEnv(ctx)...)
+	}
+	return
+}
+
+// baseEnv returns Env or, if it is nil, the parent environment filtered by
+// PassEnv and BlockEnv with the variables inherited from supervisor defaults
+func (s Spec) baseEnv() (res []string) {
+	if s.Env != nil {
+		return s.Env
+	}
+	res = []string{}
+	for _, kv := range os.Environ() {
+		name := kv
+		if i := strings.IndexByte(kv, '='); i >= 0 {
+			name = kv[:i]
+		}
+		if (len(s.PassEnv) == 0 || matchEnv(s.PassEnv, name)) && !matchEnv(s.BlockEnv, name) {
+			res = append(res, kv)
+		}
+	}
+	return append(res, s.inheritedEnv...)
+}
+
+// expanded returns the spec with ${VAR} references in Cmd, Dir and Args
+// replaced with values from env if ExpandEnv is set
+func (s Spec) expanded(env []string) Spec {
+	if !s.ExpandEnv {
+		return s
+	}
+	s.Cmd = expandVars(s.Cmd, env)
+	s.Dir = expandVars(s.Dir, env)
+	args := make([]string, len(s.Args))
+	for i, arg := range s.Args {
+		args[i] = expandVars(arg, env)
+	}
+	s.Args = args
+	return s
+}
+
+// expandVars replaces ${NAME} references with values from env, unset
+// variables expand to empty strings and other uses of $ are kept
+func expandVars(s string, env []string) string {
+	if !strings.Contains(s, "${") {
+		return s
+	}
+	var b strings.Builder
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			break
+		}
+		j := strings.IndexByte(s[i+2:], '}')
+		if j < 0 {
+			break
+		}
+		b.WriteString(s[:i])
+		b.WriteString(lookupEnv(env, s[i+2:i+2+j]))
+		s = s[i+3+j:]
+	}
+	b.WriteString(s)
+	return b.String()
+}
+
+// lookupEnv returns the last value of the variable in env
+func lookupEnv(env []string, name string) string {
+	for i := len(env) - 1; i >= 0; i-- {
+		if strings.HasPrefix(env[i], name+"=") {
+			return env[i][len(name)+1:]
+		}
+	}
+	return ""
+}
+
+// matchEnv reports whether the variable name matches any of the glob patterns
+func matchEnv(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+package process_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/andviro/process"
+)
+
+func TestRunID(t *testing.T) {
+	var stdout, stderr syncBuffer
+	spec := process.Spec{
+		Cmd:           "/bin/sh",
+		Args:          []string{"-c", "echo $PROCESS_RUN_ID; sleep 0.2"},
+		Stdout:        &stdout,
+		Stderr:        &stderr,
+		StartTimeout:  50,
+		RestartPolicy: "always",
+		MaxRestarts:   1,
+	}
+	h := spec.Run(context.TODO())
+	ids := make(map[string]bool)
+	for e := range h.Events() {
+		if e.State == process.StateRunning || e.State == process.StateRestarting {
+			ids[e.RunID] = true
+		}
+	}
+	res := h.Wait()
+	lines := strings.Fields(stdout.String())
+	if len(lines) != 2 || len(ids) != 2 {
+		t.Fatalf("invalid run IDs: %v %v", lines, ids)
+	}
+	for _, id := range lines {
+		if !ids[id] {
+			t.Errorf("run ID %s not reported in events", id)
+		}
+	}
+	if res.RunID != lines[1] {
+		t.Errorf("invalid last run ID: %s", res.RunID)
+	}
+	if !strings.Contains(stderr.String(), "["+res.RunID+"]") {
+		t.Errorf("run ID not logged: %s", stderr.String())
+	}
+}
+
+func TestPassBlockEnv(t *testing.T) {
+	os.Setenv("PROCESS_TEST_PASSED", "1")
+	os.Setenv("PROCESS_TEST_SECRET", "1")
+	defer os.Unsetenv("PROCESS_TEST_PASSED")
+	defer os.Unsetenv("PROCESS_TEST_SECRET")
+	var stdout syncBuffer
+	p := &process.Process{Spec: process.Spec{
+		Cmd:          "/usr/bin/env",
+		Stdout:       &stdout,
+		StartTimeout: 1000,
+		PassEnv:      []string{"PROCESS_TEST_*", "HOME"},
+		BlockEnv:     []string{"*_SECRET"},
+	}}
+	<-p.Run(context.TODO())
+	vars := strings.Fields(stdout.String())
+	for i := range vars {
+		vars[i] = strings.SplitN(vars[i], "=", 2)[0]
+	}
+	sort.Strings(vars)
+	if strings.Join(vars, " ") != "HOME PROCESS_RUN_ID PROCESS_TEST_PASSED" {
+		t.Errorf("invalid environment: %v", vars)
+	}
+}
+
+func TestArgsEnvFunc(t *testing.T) {
+	var stdout syncBuffer
+	p := &process.Process{Spec: process.Spec{
+		Cmd: "/bin/sh",
+		ArgsFunc: func(attempt int) []string {
+			return []string{"-c", fmt.Sprintf("echo %d $ATTEMPT; exit 1", attempt)}
+		},
+		EnvFunc: func(attempt int) []string {
+			return []string{fmt.Sprintf("ATTEMPT=%d", attempt*10)}
+		},
+		Stdout:           &stdout,
+		StartTimeout:     1000,
+		BackoffTimeout:   10,
+		RestartPolicy:    "on-failure",
+		MaxStartAttempts: 2,
+	}}
+	<-p.Run(context.TODO())
+	if out := stdout.String(); out != "1 10\n2 20\n3 30\n" {
+		t.Errorf("invalid output: %q", out)
+	}
+}
+
+type traceKey struct{}
+
+func TestContextEnv(t *testing.T) {
+	var stdout syncBuffer
+	p := &process.Process{Spec: process.Spec{
+		Cmd:  "/bin/sh",
+		Args: []string{"-c", "echo $TRACE_ID"},
+		ContextEnv: func(ctx context.Context) []string {
+			id, _ := ctx.Value(traceKey{}).(string)
+			return []string{"TRACE_ID=" + id}
+		},
+		Stdout:       &stdout,
+		StartTimeout: 1000,
+	}}
+	<-p.Run(context.WithValue(context.TODO(), traceKey{}, "abc123"))
+	if out := stdout.String(); out != "abc123\n" {
+		t.Errorf("invalid output: %q", out)
+	}
+}
+
+func TestExpandEnv(t *testing.T) {
+	home := t.TempDir()
+	if err := os.Mkdir(filepath.Join(home, "bin"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	script := filepath.Join(home, "bin", "server")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho \"$1 $2 $(pwd)\"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	var stdout syncBuffer
+	p := &process.Process{Spec: process.Spec{
+		Cmd:       "${APP_HOME}/bin/server",
+		Args:      []string{"--id=${PROCESS_RUN_ID}", "$HOME${UNSET}"},
+		Dir:       "${APP_HOME}/run",
+		CreateDir: true,
+		Env:       []string{"APP_HOME=" + home},
+		ExpandEnv: true,
+		Stdout:    &stdout,
+		Preflight: true,
+	}}
+	res := <-p.Run(context.TODO())
+	if res.Err != nil {
+		t.Fatal(res.Err)
+	}
+	expected := fmt.Sprintf("--id=%s $HOME %s\n", res.RunID, filepath.Join(home, "run"))
+	if out := stdout.String(); out != expected {
+		t.Errorf("invalid output: %q, expected %q", out, expected)
+	}
+}
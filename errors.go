@@ -0,0 +1,115 @@
+package process
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+var (
+	// ErrMaxStartAttempts is reported when the process failed to start MaxStartAttempts times in a row
+	ErrMaxStartAttempts = errors.New("maximum start attempts reached")
+	// ErrMaxRestarts is reported when the process keeps failing after MaxRestarts restarts
+	ErrMaxRestarts = errors.New("maximum restart count reached")
+	// ErrKillFailed is reported when the process survived the kill signal for KillTimeout
+	ErrKillFailed = errors.New("failed to kill process")
+	// ErrStopTimeout is reported when the process had to be killed after StopTimeout
+	ErrStopTimeout = errors.New("stop timeout exceeded")
+	// ErrNotReady is reported when the process output did not match ReadyPattern within StartTimeout
+	ErrNotReady = errors.New("process is not ready")
+	// ErrOutputLimit is reported when the process was stopped for exceeding output limit
+	ErrOutputLimit = errors.New("output limit exceeded")
+	// ErrOutputOverflow is reported when the process was stopped as its output writer fell behind by OutputBuffer
+	ErrOutputOverflow = errors.New("output buffer overflow")
+	// ErrTriggered is reported when the process was restarted by a trigger or a resource alert
+	ErrTriggered = errors.New("restart triggered")
+	// ErrAlreadyRunning is reported when Run or Reset is called on a process that has not finished yet
+	ErrAlreadyRunning = errors.New("process is already running")
+	// ErrNotRunning is reported when controlling a process that has no live child
+	ErrNotRunning = errors.New("process is not running")
+	// ErrElevation is reported when the elevation tool can't run the command without a password prompt
+	ErrElevation = errors.New("privilege elevation failed")
+	// ErrNoPathLookup is reported when Cmd is a bare name and CmdLookup disables the PATH search
+	ErrNoPathLookup = errors.New("command is not a path and PATH lookup is disabled")
+	// ErrDuplicateName is reported when adding a process under a name already taken in the supervisor
+	ErrDuplicateName = errors.New("duplicate process name")
+	// ErrNoProcess is reported when the supervisor has no process with requested name
+	ErrNoProcess = errors.New("no such process")
+	// ErrDisabled is reported when starting a process that is not enabled
+	ErrDisabled = errors.New("process is disabled")
+	// ErrUnknownPreset is reported when instantiating a template that is not registered
+	ErrUnknownPreset = errors.New("unknown preset")
+	// ErrNoTerminal is reported when attaching to a process without a running PTY child
+	ErrNoTerminal = errors.New("process has no terminal")
+)
+
+// StartError wraps an error returned by exec when launching the process
+type StartError struct {
+	Cmd string // A path to executable that failed to start
+	Err error  // Underlying exec error
+}
+
+func (e *StartError) Error() string {
+	return "error starting " + e.Cmd + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying exec error
+func (e *StartError) Unwrap() error {
+	return e.Err
+}
+
+// ExitError is reported when the child exited with nonzero status or was
+// killed by a signal
+type ExitError struct {
+	Err *exec.ExitError // Underlying exec error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying exec error
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the exit status of the child, -1 if it was killed by a
+// signal
+func (e *ExitError) ExitCode() int {
+	return e.Err.ExitCode()
+}
+
+// Signaled returns the signal that terminated the child
+func (e *ExitError) Signaled() (os.Signal, bool) {
+	if ws, ok := e.Err.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		return ws.Signal(), true
+	}
+	return nil, false
+}
+
+// wrapError tags an error with a package sentinel while keeping the original
+// error reachable for errors.Is/As
+type wrapError struct {
+	sentinel error
+	err      error
+}
+
+func wrap(sentinel, err error) error {
+	if err == nil {
+		return sentinel
+	}
+	return &wrapError{sentinel: sentinel, err: err}
+}
+
+func (e *wrapError) Error() string {
+	return e.sentinel.Error() + ": " + e.err.Error()
+}
+
+func (e *wrapError) Is(target error) bool {
+	return target == e.sentinel
+}
+
+func (e *wrapError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,119 @@
+package process_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/andviro/process"
+)
+
+func TestStartError(t *testing.T) {
+	p := &process.Process{Spec: process.Spec{
+		Cmd: "/nonexistent/binary",
+	}}
+	res := <-p.Run(context.TODO())
+	var startErr *process.StartError
+	if !errors.As(res.Err, &startErr) {
+		t.Fatalf("%#v", res.Err)
+	}
+	if !errors.Is(res.Err, os.ErrNotExist) {
+		t.Errorf("%#v", startErr.Err)
+	}
+}
+
+func TestMaxStartAttempts(t *testing.T) {
+	p := &process.Process{Spec: process.Spec{
+		Cmd:              "/bin/false",
+		RestartPolicy:    "on-failure",
+		MaxStartAttempts: 2,
+		StartTimeout:     1000,
+		BackoffTimeout:   10,
+	}}
+	res := <-p.Run(context.TODO())
+	if !errors.Is(res.Err, process.ErrMaxStartAttempts) {
+		t.Fatalf("%#v", res.Err)
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(res.Err, &exitErr) {
+		t.Errorf("%#v", res.Err)
+	}
+}
+
+func TestExitErrorSignaled(t *testing.T) {
+	p := &process.Process{Spec: process.Spec{
+		Cmd:  "/bin/sh",
+		Args: []string{"-c", "kill -KILL $$"},
+	}}
+	res := <-p.Run(context.TODO())
+	var exitErr *process.ExitError
+	if !errors.As(res.Err, &exitErr) {
+		t.Fatalf("%#v", res.Err)
+	}
+	if sig, ok := exitErr.Signaled(); !ok || sig != os.Kill || exitErr.ExitCode() != -1 {
+		t.Errorf("invalid exit: %v, %v, %d", sig, ok, exitErr.ExitCode())
+	}
+}
+
+func TestMaxRestarts(t *testing.T) {
+	p := &process.Process{Spec: process.Spec{
+		Cmd:            "/bin/sh",
+		Args:           []string{"-c", "sleep 0.2; exit 1"},
+		RestartPolicy:  "on-failure",
+		MaxRestarts:    1,
+		StartTimeout:   50,
+		RestartTimeout: 10,
+	}}
+	res := <-p.Run(context.TODO())
+	if !errors.Is(res.Err, process.ErrMaxRestarts) {
+		t.Errorf("%#v", res.Err)
+	}
+}
+
+func TestStopTimeout(t *testing.T) {
+	p := &process.Process{Spec: process.Spec{
+		Cmd:          "/bin/sh",
+		Args:         []string{"-c", "trap '' INT; sleep 3"},
+		StartTimeout: 50,
+		StopTimeout:  100,
+		KillTimeout:  1000,
+	}}
+	ctx, cancel := context.WithCancel(context.TODO())
+	go func() {
+		defer cancel()
+		time.Sleep(300 * time.Millisecond)
+	}()
+	res := <-p.Run(ctx)
+	if !errors.Is(res.Err, process.ErrStopTimeout) {
+		t.Errorf("%#v", res.Err)
+	}
+}
+
+func TestStartAttemptAccounting(t *testing.T) {
+	p := process.New("/bin/false")
+	p.BackoffTimeout = 10
+	res := <-p.Run(context.TODO())
+	if res.Attempts != 1 || res.State != process.StateStopped || res.Reason != process.ReasonCrashed {
+		t.Errorf("failed launch retried without restart policy: %+v", res)
+	}
+
+	// Clean exits during start are completed runs limited by MaxRestarts,
+	// not failed launches
+	p = &process.Process{Spec: process.Spec{
+		Cmd:            "/bin/true",
+		RestartPolicy:  "always",
+		StartTimeout:   1000,
+		MaxRestarts:    2,
+		RestartTimeout: 10,
+	}}
+	res = <-p.Run(context.TODO())
+	if res.Attempts != 3 || res.Restarts != 3 || res.Err != nil || res.Reason != process.ReasonExhausted {
+		t.Errorf("invalid clean exits accounting: %+v", res)
+	}
+	if st := p.Status(); st.StartAttempt != 0 {
+		t.Errorf("clean exits counted as failed launches: %d", st.StartAttempt)
+	}
+}
@@ -0,0 +1,95 @@
+package process
+
+import (
+	"io"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	eventlogErrorType       = 0x0001
+	eventlogWarningType     = 0x0002
+	eventlogInformationType = 0x0004
+)
+
+var (
+	advapi32                  = syscall.NewLazyDLL("advapi32.dll")
+	procRegisterEventSourceW  = advapi32.NewProc("RegisterEventSourceW")
+	procDeregisterEventSource = advapi32.NewProc("DeregisterEventSource")
+	procReportEventW          = advapi32.NewProc("ReportEventW")
+)
+
+// EventLogSink writes lifecycle events and optionally child output to the
+// Windows Event Log. The event source should be registered beforehand (e.g.
+// with eventcreate or by the service installer) for messages to be rendered
+// without warnings.
+type EventLogSink struct {
+	Output  bool   // Also write child output lines, stderr lines are logged as errors
+	EventID uint32 // Event identifier of the entries, 1 by default
+
+	handle syscall.Handle
+}
+
+// OpenEventLog registers the event source on the local machine
+func OpenEventLog(source string) (*EventLogSink, error) {
+	name, err := syscall.UTF16PtrFromString(source)
+	if err != nil {
+		return nil, err
+	}
+	h, _, err := procRegisterEventSourceW.Call(0, uintptr(unsafe.Pointer(name)))
+	if h == 0 {
+		return nil, err
+	}
+	return &EventLogSink{EventID: 1, handle: syscall.Handle(h)}, nil
+}
+
+// Writer returns line-oriented writer for the output stream if Output is set
+func (s *EventLogSink) Writer(stream Stream) io.Writer {
+	if !s.Output {
+		return nil
+	}
+	etype := uint16(eventlogInformationType)
+	if stream == StreamStderr {
+		etype = eventlogErrorType
+	}
+	return &lineWriter{fn: func(line []byte) {
+		s.report(etype, string(stream)+": "+string(line))
+	}}
+}
+
+// Event writes the state change
+func (s *EventLogSink) Event(e Event) {
+	etype := uint16(eventlogInformationType)
+	msg := e.Prev.String() + " -> " + e.State.String()
+	if e.Err != nil {
+		etype = eventlogWarningType
+		msg += ": " + e.Err.Error()
+	}
+	if e.State == StateFailed || e.State == StatePreflightFailed {
+		etype = eventlogErrorType
+	}
+	s.report(etype, msg)
+}
+
+// Close deregisters the event source
+func (s *EventLogSink) Close() error {
+	if r, _, err := procDeregisterEventSource.Call(uintptr(s.handle)); r == 0 {
+		return err
+	}
+	return nil
+}
+
+func (s *EventLogSink) report(etype uint16, msg string) error {
+	str, err := syscall.UTF16PtrFromString(msg)
+	if err != nil {
+		return err
+	}
+	strs := []*uint16{str}
+	r, _, err := procReportEventW.Call(
+		uintptr(s.handle), uintptr(etype), 0, uintptr(s.EventID), 0,
+		uintptr(len(strs)), 0, uintptr(unsafe.Pointer(&strs[0])), 0)
+	if r == 0 {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package process
+
+import (
+	"time"
+)
+
+const eventBufferSize = 16
+
+// Event is emitted on every process state change
+type Event struct {
+	Name    string        `json:"name"`    // Name of the process
+	Prev    State         `json:"prev"`    // State that has been left
+	State   State         `json:"state"`   // State that has been entered
+	RunID   string        `json:"runId"`   // Identifier of the current start attempt
+	Time    time.Time     `json:"time"`    // Time of the transition
+	Elapsed time.Duration `json:"elapsed"` // Time spent in the previous state
+	Err     error         `json:"err"`     // Last error at the moment of transition
+	Dropped int           `json:"dropped"` // Number of events dropped for the subscriber before this one
+}
+
+// EventFilter selects events delivered to a subscriber
+type EventFilter func(Event) bool
+
+// InStates selects events entering one of the states
+func InStates(states ...State) EventFilter {
+	return func(e Event) bool {
+		for _, s := range states {
+			if e.State == s {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Failures selects events carrying an error or entering a failed state
+func Failures() EventFilter {
+	return func(e Event) bool {
+		return e.Err != nil || e.State == StateFailed || e.State == StatePreflightFailed
+	}
+}
+
+type subscriber struct {
+	ch      chan Event
+	filter  EventFilter
+	dropped int
+}
+
+// Subscribe returns a stream of events selected by the filter, nil filter
+// selects all events. The stream is buffered; events that do not fit are
+// dropped and counted in the Dropped field of the next delivered event. The
+// stream is closed when the current (or the next, if the process is idle) run
+// finishes or the returned cancel function is called.
+func (p *Process) Subscribe(filter EventFilter) (<-chan Event, func()) {
+	sub := &subscriber{ch: make(chan Event, eventBufferSize), filter: filter}
+	p.mu.Lock()
+	if p.subs == nil {
+		p.subs = make(map[*subscriber]struct{})
+	}
+	p.subs[sub] = struct{}{}
+	p.mu.Unlock()
+	return sub.ch, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if _, ok := p.subs[sub]; ok {
+			delete(p.subs, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+func (p *Process) publish(e Event) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for sub := range p.subs {
+		if sub.filter != nil && !sub.filter(e) {
+			continue
+		}
+		e.Dropped = sub.dropped
+		select {
+		case sub.ch <- e:
+			sub.dropped = 0
+		default:
+			sub.dropped++
+		}
+	}
+}
+
+func (p *Process) closeSubscribers() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for sub := range p.subs {
+		close(sub.ch)
+	}
+	p.subs = nil
+}
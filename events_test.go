@@ -0,0 +1,77 @@
+package process_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/andviro/process"
+)
+
+func TestSubscribe(t *testing.T) {
+	p := &process.Process{Spec: process.Spec{
+		Cmd:              "/bin/sh",
+		Args:             []string{"-c", "exit 1"},
+		StartTimeout:     1000,
+		BackoffTimeout:   10,
+		MaxStartAttempts: 20,
+		RestartPolicy:    "on-failure",
+	}}
+	all, _ := p.Subscribe(nil)
+	slow, _ := p.Subscribe(nil)
+	failures, _ := p.Subscribe(process.Failures())
+	backoffs, cancel := p.Subscribe(process.InStates(process.StateBackoff))
+	cancel()
+	cancel()
+	if _, ok := <-backoffs; ok {
+		t.Error("cancelled subscription is not closed")
+	}
+	var failed []process.Event
+	failuresDone := make(chan struct{})
+	go func() {
+		defer close(failuresDone)
+		for e := range failures {
+			failed = append(failed, e)
+		}
+	}()
+	res := p.Run(context.TODO())
+
+	total := 0
+	for range all {
+		total++
+		if total == 20 {
+			break
+		}
+	}
+	received, dropped := 0, 0
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for e := range slow {
+			received++
+			dropped += e.Dropped
+		}
+	}()
+	for range all {
+		total++
+	}
+	<-done
+	if received+dropped != total {
+		t.Errorf("events lost: received %d, dropped %d, total %d", received, dropped, total)
+	}
+	if dropped == 0 {
+		t.Error("no events dropped")
+	}
+
+	<-failuresDone
+	for _, e := range failed {
+		if e.Err == nil && e.State != process.StateFailed {
+			t.Errorf("unexpected event: %+v", e)
+		}
+	}
+	if len(failed) == 0 || failed[len(failed)-1].State != process.StateFailed {
+		t.Errorf("invalid failures: %+v", failed)
+	}
+	if r := <-res; r.State != process.StateFailed {
+		t.Errorf("invalid final state: %v", r.State)
+	}
+}
@@ -0,0 +1,48 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// ExitCodeFor maps the outcome of a run to an exit code following shell
+// conventions: the exit status of the child, 128+N if it was killed by
+// signal N, 127 if the command was not found, 126 if it could not be
+// executed and 1 for other failures. Successful runs result in 0 unless the
+// child exited by itself with one of SuccessExitCodes, exit statuses of
+// children stopped with Stop or the context are ignored.
+func ExitCodeFor(res RunResult) int {
+	var ee *ExitError
+	switch {
+	case errors.As(res.Err, &ee):
+		if sig, ok := ee.Signaled(); ok {
+			if s, ok := sig.(syscall.Signal); ok {
+				return 128 + int(s)
+			}
+		}
+		if code := ee.ExitCode(); code > 0 {
+			return code
+		}
+		return 1
+	case res.Err == nil:
+		if res.Reason == ReasonExited && res.ExitCode > 0 {
+			return res.ExitCode
+		}
+		return 0
+	case errors.Is(res.Err, exec.ErrNotFound), errors.Is(res.Err, os.ErrNotExist):
+		return 127
+	case errors.Is(res.Err, os.ErrPermission):
+		return 126
+	}
+	return 1
+}
+
+// RunAndExit runs the process to completion and exits the program with
+// ExitCodeFor the result, so that a wrapper or container entrypoint reports
+// the status of the supervised child. Deferred functions are not run.
+func (p *Process) RunAndExit(ctx context.Context) {
+	os.Exit(ExitCodeFor(<-p.Run(ctx)))
+}
@@ -0,0 +1,68 @@
+package process_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/andviro/process"
+)
+
+func TestExitCodeFor(t *testing.T) {
+	dir := t.TempDir()
+	for _, tc := range []struct {
+		spec     process.Spec
+		expected int
+	}{
+		{process.Spec{Cmd: "/bin/true"}, 0},
+		{process.Spec{Cmd: "/bin/sh", Args: []string{"-c", "exit 3"}}, 3},
+		{process.Spec{Cmd: "/bin/sh", Args: []string{"-c", "exit 3"}, SuccessExitCodes: []int{3}}, 3},
+		{process.Spec{Cmd: "/bin/sh", Args: []string{"-c", "kill -KILL $$"}}, 137},
+		{process.Spec{Cmd: "/nonexistent/command"}, 127},
+		{process.Spec{Cmd: dir}, 126},
+	} {
+		res := <-(&process.Process{Spec: tc.spec}).Run(context.TODO())
+		if code := process.ExitCodeFor(res); code != tc.expected {
+			t.Errorf("%s: exit code %d, expected %d (%v)", tc.spec.DisplayCommand(), code, tc.expected, res.Err)
+		}
+	}
+}
+
+func TestExitCodeForStop(t *testing.T) {
+	p := &process.Process{Spec: process.Spec{
+		Cmd:          "/bin/sh",
+		Args:         []string{"-c", "trap 'exit 143' TERM INT; while :; do sleep 0.05; done"},
+		StartTimeout: 50,
+		StopTimeout:  1000,
+	}}
+	ctx, cancel := context.WithTimeout(context.TODO(), 200*time.Millisecond)
+	defer cancel()
+	res := <-p.Run(ctx)
+	if res.Err != nil || res.ExitCode != 143 {
+		t.Fatalf("invalid result: %+v", res)
+	}
+	if code := process.ExitCodeFor(res); code != 0 {
+		t.Errorf("exit code %d after stop", code)
+	}
+}
+
+// TestRunAndExitHelper is run as a child by TestRunAndExit
+func TestRunAndExitHelper(t *testing.T) {
+	if os.Getenv("PROCESS_TEST_EXIT") != "1" {
+		t.Skip("helper process")
+	}
+	p := &process.Process{Spec: process.Spec{Cmd: "/bin/sh", Args: []string{"-c", "kill -TERM $$"}}}
+	p.RunAndExit(context.TODO())
+}
+
+func TestRunAndExit(t *testing.T) {
+	cmd := exec.Command(os.Args[0], "-test.run=^TestRunAndExitHelper$")
+	cmd.Env = append(os.Environ(), "PROCESS_TEST_EXIT=1")
+	var ee *exec.ExitError
+	if err := cmd.Run(); !errors.As(err, &ee) || ee.ExitCode() != 143 {
+		t.Errorf("invalid exit: %v", err)
+	}
+}
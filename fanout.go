@@ -0,0 +1,71 @@
+package process
+
+import (
+	"context"
+	"strconv"
+)
+
+// IndexEnv is the name of environment variable holding the instance number
+// of processes started by RunN
+const IndexEnv = "INDEX"
+
+// Instances controls the processes started by RunN
+type Instances struct {
+	handles []*Handle
+	done    chan struct{}
+}
+
+// RunN starts n processes from the spec, a lightweight alternative to
+// Supervisor for homogeneous workers. Instances are numbered from 0: the
+// number is passed to the child in IndexEnv before the variables of EnvFunc
+// and appended to the process name.
+func RunN(ctx context.Context, n int, spec Spec) *Instances {
+	res := &Instances{done: make(chan struct{})}
+	for i := 0; i < n; i++ {
+		s := spec.Clone()
+		s.Name = s.procName() + "-" + strconv.Itoa(i)
+		index, envFunc := IndexEnv+"="+strconv.Itoa(i), spec.EnvFunc
+		s.EnvFunc = func(attempt int) []string {
+			env := []string{index}
+			if envFunc != nil {
+				env = append(env, envFunc(attempt)...)
+			}
+			return env
+		}
+		res.handles = append(res.handles, s.Run(ctx))
+	}
+	go func() {
+		defer close(res.done)
+		for _, h := range res.handles {
+			<-h.Done()
+		}
+	}()
+	return res
+}
+
+// Handles returns the handles of instances in order of their numbers
+func (in *Instances) Handles() []*Handle {
+	return append([]*Handle(nil), in.handles...)
+}
+
+// Stop initiates shutdown of all instances
+func (in *Instances) Stop() {
+	for _, h := range in.handles {
+		h.Stop()
+	}
+}
+
+// Wait blocks until all instances reach their final states and returns the
+// outcomes in order of instance numbers
+func (in *Instances) Wait() []RunResult {
+	res := make([]RunResult, len(in.handles))
+	for i, h := range in.handles {
+		res[i] = h.Wait()
+	}
+	return res
+}
+
+// Done returns a channel closed when all instances reach their final states
+func (in *Instances) Done() <-chan struct{} {
+	return in.done
+}
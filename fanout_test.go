@@ -0,0 +1,50 @@
+package process_test
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andviro/process"
+)
+
+func TestRunN(t *testing.T) {
+	var stdout syncBuffer
+	spec := process.Spec{
+		Cmd:          "/bin/sh",
+		Args:         []string{"-c", `echo "worker $INDEX $EXTRA"; exec sleep 3`},
+		Stdout:       &stdout,
+		EnvFunc:      func(int) []string { return []string{"EXTRA=x"} },
+		StartTimeout: 50,
+		StopTimeout:  1000,
+	}
+	in := process.RunN(context.TODO(), 3, spec)
+	time.Sleep(200 * time.Millisecond)
+	for i, h := range in.Handles() {
+		if st := h.Status(); st.State != process.StateRunning {
+			t.Errorf("instance %d is %s", i, st.State)
+		}
+	}
+	in.Stop()
+	select {
+	case <-in.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("instances not stopped")
+	}
+	res := in.Wait()
+	if len(res) != 3 {
+		t.Fatalf("invalid results: %+v", res)
+	}
+	for i, r := range res {
+		if r.State != process.StateStopped {
+			t.Errorf("instance %d is %s", i, r.State)
+		}
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	sort.Strings(lines)
+	if strings.Join(lines, ",") != "worker 0 x,worker 1 x,worker 2 x" {
+		t.Errorf("invalid output: %q", lines)
+	}
+}
@@ -0,0 +1,124 @@
+package process
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+)
+
+// FileSink appends child output to a file, optionally interleaved with
+// lifecycle events. The file can be reopened after external rotation.
+type FileSink struct {
+	Events bool        // Write lifecycle events as separate lines
+	Mode   os.FileMode // Permissions for the created file
+
+	path string
+	mu   sync.Mutex
+	f    *os.File
+}
+
+// OpenFileSink opens the file for appending, creating it if needed
+func OpenFileSink(path string) (res *FileSink, err error) {
+	res = &FileSink{path: path, Mode: 0644}
+	if err = res.Reopen(); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// Writer returns the sink itself for both streams
+func (s *FileSink) Writer(stream Stream) io.Writer {
+	return s
+}
+
+// Event writes the state change if Events is set
+func (s *FileSink) Event(e Event) {
+	if !s.Events {
+		return
+	}
+	msg := fmt.Sprintf("%s %v -> %v", e.Time.Format(time.RFC3339), e.Prev, e.State)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	s.Write([]byte(msg + "\n"))
+}
+
+func (s *FileSink) Write(b []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return 0, os.ErrClosed
+	}
+	return s.f.Write(b)
+}
+
+// Reopen closes and opens the file again, so that writes go to the new file
+// after it has been moved away by logrotate
+func (s *FileSink) Reopen() error {
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, s.Mode)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f != nil {
+		s.f.Close()
+	}
+	s.f = f
+	return nil
+}
+
+// Rotate renames the current file with ".1" suffix and starts a new one
+func (s *FileSink) Rotate() error {
+	if err := os.Rename(s.path, s.path+".1"); err != nil {
+		return err
+	}
+	return s.Reopen()
+}
+
+// ReopenOn reopens the file every time one of the signals is received.
+// Without arguments the platform default is used: SIGUSR1 where available.
+// The returned function stops signal handling.
+func (s *FileSink) ReopenOn(sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = reopenSignals
+	}
+	if len(sigs) == 0 {
+		return func() {}
+	}
+	c := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(c, sigs...)
+	go func() {
+		for {
+			select {
+			case <-c:
+				s.Reopen()
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(c)
+			close(done)
+		})
+	}
+}
+
+// Close closes the file
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return nil
+	}
+	err := s.f.Close()
+	s.f = nil
+	return err
+}
@@ -0,0 +1,76 @@
+package process_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/andviro/process"
+)
+
+func readFile(t *testing.T, path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	return string(data)
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.log")
+	sink, err := process.OpenFileSink(path)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	defer sink.Close()
+	sink.Events = true
+
+	p := &process.Process{Spec: process.Spec{
+		Cmd:  "/bin/sh",
+		Args: []string{"-c", "echo first"},
+		Sink: sink,
+	}}
+	<-p.Run(context.TODO())
+	if err := os.Rename(path, path+".old"); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if err := sink.Reopen(); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	p.Args = []string{"-c", "echo second"}
+	<-p.Run(context.TODO())
+
+	if out := readFile(t, path+".old"); !strings.Contains(out, "first\n") || !strings.Contains(out, "idle -> starting\n") {
+		t.Errorf("invalid rotated file: %q", out)
+	}
+	if out := readFile(t, path); !strings.Contains(out, "second\n") || strings.Contains(out, "first") {
+		t.Errorf("invalid file: %q", out)
+	}
+}
+
+func TestFileSinkRotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.log")
+	sink, err := process.OpenFileSink(path)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	defer sink.Close()
+
+	p := &process.Process{Spec: process.Spec{
+		Cmd:               "/bin/sh",
+		Args:              []string{"-c", "echo 0123; sleep 0.05; echo 4567"},
+		Sink:              sink,
+		StdoutLimit:       6,
+		StartTimeout:      1000,
+		OutputLimitAction: process.LimitRotate,
+	}}
+	<-p.Run(context.TODO())
+	if out := readFile(t, path+".1"); out != "0123\n" {
+		t.Errorf("invalid rotated file: %q", out)
+	}
+	if out := readFile(t, path); out != "4567\n" {
+		t.Errorf("invalid file: %q", out)
+	}
+}
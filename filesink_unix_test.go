@@ -0,0 +1,44 @@
+//go:build !windows
+
+package process_test
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/andviro/process"
+)
+
+func TestFileSinkReopenOnSignal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.log")
+	sink, err := process.OpenFileSink(path)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	defer sink.Close()
+	stop := sink.ReopenOn()
+	defer stop()
+
+	sink.Write([]byte("first\n"))
+	if err := os.Rename(path, path+".old"); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("file not reopened")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	sink.Write([]byte("second\n"))
+	if out := readFile(t, path); out != "second\n" {
+		t.Errorf("invalid file: %q", out)
+	}
+}
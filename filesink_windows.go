@@ -0,0 +1,7 @@
+package process
+
+import (
+	"os"
+)
+
+var reopenSignals []os.Signal
@@ -0,0 +1,35 @@
+package process
+
+import (
+	"time"
+)
+
+// watchChildren polls descendants of the child and reports new ones to
+// OnFork until done is closed
+func (p *Process) watchChildren(pid int, done <-chan struct{}) {
+	t := time.NewTicker(time.Duration(p.WatchChildren) * time.Millisecond)
+	defer t.Stop()
+	known := make(map[int]bool)
+	for {
+		for _, pi := range descendants(listProcs(), pid) {
+			if !known[pi.Pid] {
+				known[pi.Pid] = true
+				p.OnFork(pi)
+			}
+		}
+		select {
+		case <-done:
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// checkSurvivors reports processes spawned by the exited child that are
+// still alive
+func (p *Process) checkSurvivors() {
+	if procs := runIDProcs(p.RunID); len(procs) > 0 {
+		p.warnf("%d descendants survived the child", len(procs))
+		p.OnSurvivors(procs)
+	}
+}
@@ -0,0 +1,48 @@
+package process_test
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/andviro/process"
+)
+
+func TestForks(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("process table is only supported on Linux")
+	}
+	var (
+		mu        sync.Mutex
+		forks     []process.ProcInfo
+		survivors []process.ProcInfo
+	)
+	p := &process.Process{Spec: process.Spec{
+		Cmd:           "/bin/sh",
+		Args:          []string{"-c", "(exec sleep 2) & sleep 0.3"},
+		StartTimeout:  50,
+		WatchChildren: 20,
+		OnFork: func(pi process.ProcInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			forks = append(forks, pi)
+		},
+		OnSurvivors: func(procs []process.ProcInfo) { survivors = procs },
+	}}
+	<-p.Run(context.TODO())
+	for _, pi := range survivors {
+		if proc, err := os.FindProcess(pi.Pid); err == nil {
+			proc.Kill()
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(forks) != 2 {
+		t.Errorf("invalid forks: %+v", forks)
+	}
+	if len(survivors) != 1 || survivors[0].Cmdline[0] != "sleep" || survivors[0].Cmdline[1] != "2" {
+		t.Errorf("invalid survivors: %+v", survivors)
+	}
+}
@@ -0,0 +1,67 @@
+package process
+
+import (
+	"bytes"
+	"io"
+	"sync/atomic"
+	"text/template"
+	"time"
+)
+
+// DefaultLineTemplate is used when LineFormat.Template is empty
+const DefaultLineTemplate = "{{.Time}} {{.Name}}[{{.Pid}}] {{.Stream}}: {{.Line}}"
+
+// LineFormat configures prefixing of child output lines written to Stdout
+// and Stderr
+type LineFormat struct {
+	Template   string `json:"template"`   // text/template over LineInfo fields
+	TimeLayout string `json:"timeLayout"` // Layout of LineInfo.Time, RFC3339 by default
+}
+
+// LineInfo is passed to the line template
+type LineInfo struct {
+	Time   string // Time when the line was written
+	Name   string // Process name
+	Stream Stream // Output stream
+	Pid    int    // Pid of the child
+	Line   string // Line without the trailing newline
+}
+
+func (f *LineFormat) compile() (*template.Template, error) {
+	text := f.Template
+	if text == "" {
+		text = DefaultLineTemplate
+	}
+	return template.New("line").Parse(text)
+}
+
+func (f *LineFormat) timeLayout() string {
+	if f.TimeLayout == "" {
+		return time.RFC3339
+	}
+	return f.TimeLayout
+}
+
+// formatWriter prefixes every line written to w according to LineFormat
+func (p *Process) formatWriter(stream Stream, w io.Writer, tpl *template.Template) io.Writer {
+	if tpl == nil || w == nil {
+		return w
+	}
+	layout := p.LineFormat.timeLayout()
+	name := p.procName()
+	var buf bytes.Buffer
+	lw := &lineWriter{fn: func(line []byte) {
+		buf.Reset()
+		tpl.Execute(&buf, LineInfo{
+			Time:   time.Now().Format(layout),
+			Name:   name,
+			Stream: stream,
+			Pid:    int(atomic.LoadInt32(&p.pid)),
+			Line:   string(line),
+		})
+		buf.WriteByte('\n')
+		w.Write(buf.Bytes())
+	}}
+	p.flushers = append(p.flushers, lw)
+	return lw
+}
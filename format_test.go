@@ -0,0 +1,43 @@
+package process_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/andviro/process"
+)
+
+func TestLineFormat(t *testing.T) {
+	var stdout, stderr syncBuffer
+	p := &process.Process{Spec: process.Spec{
+		Cmd:    "/bin/sh",
+		Args:   []string{"-c", "sleep 0.1; echo $$; echo oops >&2"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+		LineFormat: &process.LineFormat{
+			Template:   "{{.Time}} {{.Stream}} {{.Name}}[{{.Pid}}] {{.Line}}",
+			TimeLayout: "15:04:05",
+		},
+	}}
+	<-p.Run(context.TODO())
+	m := regexp.MustCompile(`^\d\d:\d\d:\d\d stdout sh\[(\d+)\] (\d+)\n$`).FindStringSubmatch(stdout.String())
+	if m == nil || m[1] != m[2] {
+		t.Fatalf("invalid stdout: %q", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), fmt.Sprintf(" stderr sh[%s] oops\n", m[1])) {
+		t.Errorf("invalid stderr: %q", stderr.String())
+	}
+}
+
+func TestLineFormatInvalid(t *testing.T) {
+	s := process.NewSpec("/bin/true")
+	s.LineFormat = &process.LineFormat{Template: "{{.Line"}
+	var verr *process.ValidationError
+	if !errors.As(s.Validate(), &verr) || len(verr.Errors) != 1 {
+		t.Errorf("invalid validation result: %v", s.Validate())
+	}
+}
@@ -0,0 +1,43 @@
+package process
+
+import (
+	"context"
+)
+
+// Group runs several processes under one context like errgroup: by default
+// the first process whose run finishes with error stops the others.
+type Group struct {
+	Processes []*Process // Processes run together
+	WaitAll   bool       // Let the other processes run to completion after a failure
+}
+
+// Run runs all processes until they finish and returns their outcomes in
+// order along with the error of the first failed run. Runs stopped because
+// of the failure are not failures themselves.
+func (g Group) Run(ctx context.Context) ([]RunResult, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	type finished struct {
+		i int
+		r RunResult
+	}
+	done := make(chan finished, len(g.Processes))
+	for i, p := range g.Processes {
+		i, results := i, p.Run(ctx)
+		go func() { done <- finished{i, <-results} }()
+	}
+	res := make([]RunResult, len(g.Processes))
+	var err error
+	for range g.Processes {
+		f := <-done
+		res[f.i] = f.r
+		if f.r.Err == nil || err != nil {
+			continue
+		}
+		err = f.r.Err
+		if !g.WaitAll {
+			cancel()
+		}
+	}
+	return res, err
+}
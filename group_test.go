@@ -0,0 +1,43 @@
+package process_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/andviro/process"
+)
+
+func TestGroup(t *testing.T) {
+	procs := func() []*process.Process {
+		return []*process.Process{
+			{Spec: process.Spec{Cmd: "/bin/sleep", Args: []string{"0.3"}, StopTimeout: 1000}},
+			{Spec: process.Spec{Cmd: "/bin/sh", Args: []string{"-c", "sleep 0.1; exit 4"}}},
+		}
+	}
+	started := time.Now()
+	res, err := process.Group{Processes: procs()}.Run(context.TODO())
+	var exitErr *process.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 4 {
+		t.Errorf("invalid error: %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > 250*time.Millisecond {
+		t.Errorf("group not stopped on failure: %v", elapsed)
+	}
+	if res[0].Err != nil || res[0].Reason != process.ReasonCanceled {
+		t.Errorf("invalid result: %+v", res[0])
+	}
+
+	started = time.Now()
+	res, err = process.Group{Processes: procs(), WaitAll: true}.Run(context.TODO())
+	if !errors.As(err, &exitErr) {
+		t.Errorf("invalid error: %v", err)
+	}
+	if elapsed := time.Since(started); elapsed < 300*time.Millisecond {
+		t.Errorf("group stopped early: %v", elapsed)
+	}
+	if res[0].Err != nil || res[0].Reason != process.ReasonExited {
+		t.Errorf("invalid result: %+v", res[0])
+	}
+}
@@ -0,0 +1,122 @@
+package process
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Status is a consistent snapshot of process run-time parameters
+type Status struct {
+	State        State  `json:"state"`        // Current process state
+	Pid          int    `json:"pid"`          // Pid of the running child, 0 if there's none
+	StartAttempt int    `json:"startAttempt"` // Number of consecutive failed launches
+	RestartCount int    `json:"restartCount"` // Current number of runs
+	LastError    error  `json:"lastError"`    // Last error encountered
+	RunID        string `json:"runId"`        // Identifier of the current start attempt
+	Unhealthy    string `json:"unhealthy"`    // Output line that marked the process unhealthy, empty if healthy
+
+	Since     time.Time               `json:"since"`     // Time when the current state was entered
+	Durations map[State]time.Duration `json:"durations"` // Time spent in each state when it was last left
+	StartedAt time.Time               `json:"startedAt"` // Time when the current or last child was started
+	StoppedAt time.Time               `json:"stoppedAt"` // Time when the last child exited, zero if none did
+
+	Uptime     time.Duration `json:"uptime"`     // Total time spent running
+	Downtime   time.Duration `json:"downtime"`   // Total time spent starting, restarting or stopping
+	CleanExits int           `json:"cleanExits"` // Number of successful or requested exits
+	CrashExits int           `json:"crashExits"` // Number of exits with error
+
+	DroppedOutput int64 `json:"droppedOutput"` // Bytes of output discarded on OutputBuffer overflow
+
+	Reason StopReason `json:"reason"` // Why supervision has finished, empty until then
+}
+
+// Elapsed returns time spent in the current state
+func (s Status) Elapsed() time.Duration {
+	if s.Since.IsZero() {
+		return 0
+	}
+	return time.Since(s.Since)
+}
+
+// Handle controls a process started from Spec
+type Handle struct {
+	p       *Process
+	events  <-chan Event
+	done    chan struct{}
+	result  RunResult
+	started int32 // Set atomically by Start or Spec.Run
+}
+
+// NewHandle creates a process from the spec without starting it, like
+// exec.Command. The process is run with Start.
+func NewHandle(s Spec) *Handle {
+	res := &Handle{
+		p:    &Process{Spec: s.Clone()},
+		done: make(chan struct{}),
+	}
+	res.events, _ = res.p.Subscribe(nil)
+	return res
+}
+
+// Start runs the process and blocks until it is running, mirroring
+// exec.Cmd.Start; the outcome is returned by Wait. If the run finishes
+// before that, e.g. the child failed to launch or exited within
+// StartTimeout, the error of the run or ErrNotReady is returned. A handle
+// can be started once.
+func (h *Handle) Start(ctx context.Context) error {
+	if !atomic.CompareAndSwapInt32(&h.started, 0, 1) {
+		return ErrAlreadyRunning
+	}
+	running, cancel := h.p.Subscribe(InStates(StateRunning))
+	defer cancel()
+	h.run(ctx)
+	if _, ok := <-running; ok {
+		return nil
+	}
+	if err := h.Wait().Err; err != nil {
+		return err
+	}
+	return ErrNotReady
+}
+
+func (h *Handle) run(ctx context.Context) {
+	atomic.StoreInt32(&h.started, 1)
+	results := h.p.Run(ctx)
+	go func() {
+		defer close(h.done)
+		h.result = <-results
+	}()
+}
+
+// Stop initiates process shutdown
+func (h *Handle) Stop() {
+	h.p.Stop()
+}
+
+// Status returns current status of the process
+func (h *Handle) Status() Status {
+	return h.p.Status()
+}
+
+// Wait blocks until the process reaches its final state and returns the
+// outcome. Waiting for a handle that was not started results in
+// ErrNotRunning.
+func (h *Handle) Wait() RunResult {
+	if atomic.LoadInt32(&h.started) == 0 {
+		return RunResult{ExitCode: -1, Err: ErrNotRunning}
+	}
+	<-h.done
+	return h.result
+}
+
+// Done returns a channel closed when the process reaches its final state
+func (h *Handle) Done() <-chan struct{} {
+	return h.done
+}
+
+// Events returns the stream of state changes. The stream is closed after the
+// final state. Events are dropped if the channel is not read fast enough.
+func (h *Handle) Events() <-chan Event {
+	return h.events
+}
@@ -0,0 +1,194 @@
+package process_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/andviro/process"
+)
+
+func TestHandle(t *testing.T) {
+	spec := process.Spec{
+		Cmd:          "/bin/sleep",
+		Args:         []string{"3"},
+		StartTimeout: 100,
+		StopTimeout:  1000,
+	}
+	h := spec.Run(context.TODO())
+	var states []process.State
+	for e := range h.Events() {
+		states = append(states, e.State)
+		if e.State != process.StateRunning {
+			continue
+		}
+		st := h.Status()
+		if st.State != process.StateRunning || st.Pid == 0 {
+			t.Errorf("invalid status: %+v", st)
+		}
+		h.Stop()
+	}
+	expected := []process.State{process.StateStarting, process.StateRunning, process.StateStopping, process.StateStopped}
+	if len(states) != len(expected) {
+		t.Fatalf("invalid states: %v", states)
+	}
+	for i := range expected {
+		if states[i] != expected[i] {
+			t.Errorf("invalid state %d: %s", i, states[i])
+		}
+	}
+	if res := h.Wait(); res.State != process.StateStopped {
+		t.Errorf("invalid final state: %s", res.State)
+	}
+}
+
+func TestHandleStart(t *testing.T) {
+	h := process.NewHandle(process.Spec{Cmd: "/bin/sleep", Args: []string{"3"}, StartTimeout: 100, StopTimeout: 1000})
+	if res := h.Wait(); !errors.Is(res.Err, process.ErrNotRunning) {
+		t.Errorf("invalid result before start: %+v", res)
+	}
+	started := time.Now()
+	if err := h.Start(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	if st := h.Status(); st.State != process.StateRunning || time.Since(started) < 100*time.Millisecond {
+		t.Errorf("invalid status: %+v", st)
+	}
+	if err := h.Start(context.TODO()); !errors.Is(err, process.ErrAlreadyRunning) {
+		t.Errorf("invalid error: %v", err)
+	}
+	h.Stop()
+	if res := h.Wait(); res.State != process.StateStopped {
+		t.Errorf("invalid final state: %s", res.State)
+	}
+
+	h = process.NewHandle(process.Spec{Cmd: "/nonexistent/command"})
+	var serr *process.StartError
+	if err := h.Start(context.TODO()); !errors.As(err, &serr) || h.Wait().State != process.StateFailed {
+		t.Errorf("invalid error: %v", err)
+	}
+	h = process.NewHandle(process.Spec{Cmd: "/bin/true", StartTimeout: 1000})
+	if err := h.Start(context.TODO()); !errors.Is(err, process.ErrNotReady) {
+		t.Errorf("exited child reported as started: %v", err)
+	}
+}
+
+func TestSpecReuse(t *testing.T) {
+	spec := process.Spec{
+		Cmd:  "/bin/sh",
+		Args: []string{"-c", "exit 0"},
+	}
+	h1, h2 := spec.Run(context.TODO()), spec.Run(context.TODO())
+	for _, h := range []*process.Handle{h1, h2} {
+		res := h.Wait()
+		if res.Err != nil || res.Attempts != 1 {
+			t.Errorf("invalid result: %+v", res)
+		}
+	}
+}
+
+func TestStateDurations(t *testing.T) {
+	spec := process.Spec{
+		Cmd:          "/bin/sleep",
+		Args:         []string{"0.3"},
+		StartTimeout: 100,
+	}
+	h := spec.Run(context.TODO())
+	for e := range h.Events() {
+		switch e.State {
+		case process.StateStarting:
+			if e.Elapsed != 0 {
+				t.Errorf("invalid elapsed time for the first state: %v", e.Elapsed)
+			}
+		case process.StateRunning:
+			if e.Elapsed < 100*time.Millisecond {
+				t.Errorf("invalid time in starting state: %v", e.Elapsed)
+			}
+			if st := h.Status(); st.Since != e.Time {
+				t.Errorf("invalid state timestamp: %v", st.Since)
+			}
+		}
+	}
+	h.Wait()
+	st := h.Status()
+	if d := st.Durations[process.StateStarting]; d < 100*time.Millisecond {
+		t.Errorf("invalid time in starting state: %v", d)
+	}
+	if d := st.Durations[process.StateRunning]; d < 150*time.Millisecond {
+		t.Errorf("invalid time in running state: %v", d)
+	}
+	if st.Elapsed() <= 0 {
+		t.Errorf("invalid time in final state: %v", st.Elapsed())
+	}
+}
+
+func TestUptime(t *testing.T) {
+	spec := process.Spec{
+		Cmd:            "/bin/sh",
+		Args:           []string{"-c", "sleep 0.2; exit 1"},
+		StartTimeout:   50,
+		RestartPolicy:  "on-failure",
+		MaxRestarts:    1,
+		RestartTimeout: 10,
+	}
+	h := spec.Run(context.TODO())
+	h.Wait()
+	st := h.Status()
+	if st.CrashExits != 2 || st.CleanExits != 0 {
+		t.Errorf("invalid exit counts: %d, %d", st.CleanExits, st.CrashExits)
+	}
+	if st.Uptime < 250*time.Millisecond {
+		t.Errorf("invalid uptime: %v", st.Uptime)
+	}
+	if st.Downtime < 100*time.Millisecond || st.Downtime > st.Uptime {
+		t.Errorf("invalid downtime: %v", st.Downtime)
+	}
+}
+
+func TestOnStateChange(t *testing.T) {
+	type transition struct {
+		prev, next process.State
+		err        error
+	}
+	var log []transition
+	p := &process.Process{Spec: process.Spec{
+		Cmd: "/nonexistent/binary",
+		OnStateChange: func(prev, next process.State, err error, at time.Time) {
+			log = append(log, transition{prev, next, err})
+		},
+	}}
+	<-p.Run(context.TODO())
+	if len(log) != 2 {
+		t.Fatalf("invalid transitions: %v", log)
+	}
+	if log[0].prev != process.StateIdle || log[0].next != process.StateStarting || log[0].err != nil {
+		t.Errorf("invalid first transition: %+v", log[0])
+	}
+	var startErr *process.StartError
+	if log[1].prev != process.StateStarting || log[1].next != process.StateFailed || !errors.As(log[1].err, &startErr) {
+		t.Errorf("invalid second transition: %+v", log[1])
+	}
+}
+
+func TestStatusTimestamps(t *testing.T) {
+	spec := process.Spec{
+		Cmd:          "/bin/sleep",
+		Args:         []string{"0.2"},
+		StartTimeout: 50,
+	}
+	before := time.Now()
+	h := spec.Run(context.TODO())
+	for e := range h.Events() {
+		if e.State != process.StateRunning {
+			continue
+		}
+		if st := h.Status(); st.StartedAt.Before(before) || !st.StoppedAt.IsZero() {
+			t.Errorf("invalid running timestamps: %v, %v", st.StartedAt, st.StoppedAt)
+		}
+	}
+	h.Wait()
+	if st := h.Status(); st.StoppedAt.Sub(st.StartedAt) < 200*time.Millisecond {
+		t.Errorf("invalid final timestamps: %v, %v", st.StartedAt, st.StoppedAt)
+	}
+}
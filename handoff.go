@@ -0,0 +1,299 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// Handoff describes a running child handed over to a new supervisor image
+// with Supervisor.Reexec
+type Handoff struct {
+	Stdout    int       `json:"stdout"`    // Inherited descriptor of the stdout pipe read end, -1 if not piped
+	Stderr    int       `json:"stderr"`    // Inherited descriptor of the stderr pipe read end, -1 if not piped
+	StartedAt time.Time `json:"startedAt"` // Time when the child was started
+}
+
+// handoff is a child inherited from the previous supervisor image, waiting
+// for the process to resume supervision in starting
+type handoff struct {
+	proc           *os.Process
+	runID          string
+	started        time.Time
+	stdout, stderr *os.File
+}
+
+// outputPipe carries a child output stream through a pipe owned by the
+// process rather than by exec, so that the read end can be handed over
+type outputPipe struct {
+	stream Stream
+	r      *os.File
+	dst    io.Writer
+	done   chan struct{} // Closed when the copying has finished
+	paused chan struct{} // Signalled when the copying is paused for handoff
+	resume chan struct{} // Resumes the paused copying
+}
+
+func newOutputPipe(stream Stream, r *os.File, dst io.Writer) *outputPipe {
+	return &outputPipe{
+		stream: stream,
+		r:      r,
+		dst:    dst,
+		done:   make(chan struct{}),
+		paused: make(chan struct{}),
+		resume: make(chan struct{}),
+	}
+}
+
+// copy passes the stream to the writer until the pipe is closed. The read
+// end is closed if the writer fails, like with exec, so that the child gets
+// EPIPE.
+func (o *outputPipe) copy() {
+	defer close(o.done)
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := o.r.Read(buf)
+		if n > 0 {
+			if _, werr := o.dst.Write(buf[:n]); werr != nil {
+				o.r.Close()
+				return
+			}
+		}
+		switch {
+		case errors.Is(err, os.ErrDeadlineExceeded):
+			o.paused <- struct{}{}
+			<-o.resume
+		case err != nil:
+			return
+		}
+	}
+}
+
+// pause stops copying leaving unread data in the pipe, false if the stream
+// has already ended
+func (o *outputPipe) pause() bool {
+	if o.r.SetReadDeadline(time.Now()) != nil {
+		return false
+	}
+	select {
+	case <-o.paused:
+		return true
+	case <-o.done:
+		return false
+	}
+}
+
+// unpause resumes copying stopped with pause
+func (o *outputPipe) unpause() {
+	o.r.SetReadDeadline(time.Time{})
+	o.resume <- struct{}{}
+}
+
+// pipeOutputs creates pipes for the child output streams, the write ends are
+// closed once the child is started. Nil writers are left to exec.
+func pipeOutputs(cmd *exec.Cmd, stdout, stderr io.Writer) (outs []*outputPipe, err error) {
+	for _, s := range []struct {
+		stream Stream
+		dst    io.Writer
+		fd     *io.Writer
+	}{{StreamStdout, stdout, &cmd.Stdout}, {StreamStderr, stderr, &cmd.Stderr}} {
+		if s.dst == nil {
+			continue
+		}
+		r, w, err := os.Pipe()
+		if err != nil {
+			closeOutputs(outs)
+			closeWriters(cmd)
+			return nil, fmt.Errorf("output pipe: %w", err)
+		}
+		*s.fd = w
+		outs = append(outs, newOutputPipe(s.stream, r, s.dst))
+	}
+	return outs, nil
+}
+
+// closeWriters closes the parent copies of the pipe write ends
+func closeWriters(cmd *exec.Cmd) {
+	for _, w := range []io.Writer{cmd.Stdout, cmd.Stderr} {
+		if f, ok := w.(*os.File); ok {
+			f.Close()
+		}
+	}
+}
+
+func closeOutputs(outs []*outputPipe) {
+	for _, o := range outs {
+		o.r.Close()
+	}
+}
+
+// drainOutputs waits for the output to be copied after the child has
+// exited. If descendants of the child keep the pipes open longer than
+// delay, the pipes are closed like with exec.Cmd WaitDelay.
+func drainOutputs(outs []*outputPipe, delay time.Duration) {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	for _, o := range outs {
+		select {
+		case <-o.done:
+			continue
+		case <-timer.C:
+		}
+		closeOutputs(outs)
+		break
+	}
+	for _, o := range outs {
+		<-o.done
+	}
+	closeOutputs(outs)
+}
+
+// attach passes the inherited output of the child to the writers
+func (h *handoff) attach(stdout, stderr io.Writer) (outs []*outputPipe) {
+	for _, s := range []struct {
+		stream Stream
+		r      *os.File
+		dst    io.Writer
+	}{{StreamStdout, h.stdout, stdout}, {StreamStderr, h.stderr, stderr}} {
+		switch {
+		case s.r == nil:
+		case s.dst == nil:
+			outs = append(outs, newOutputPipe(s.stream, s.r, io.Discard))
+		default:
+			outs = append(outs, newOutputPipe(s.stream, s.r, s.dst))
+		}
+	}
+	return
+}
+
+// takeHandoff returns the inherited child once
+func (p *Process) takeHandoff() *handoff {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	h := p.handoff
+	p.handoff = nil
+	return h
+}
+
+// handedOver reports whether the process has a child inherited by Resume
+func (p *Process) handedOver() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.handoff != nil
+}
+
+// handOver pauses the output of the running child and returns its record
+// with the pipe descriptors left open across exec. The returned function
+// cancels the handoff.
+func (p *Process) handOver(name string) (r Record, cancel func(), ok bool) {
+	p.mu.RLock()
+	outs := p.outs
+	p.mu.RUnlock()
+	r = p.Record(name)
+	if !r.State.up() || r.Pid == 0 {
+		return r, nil, false
+	}
+	st := p.Status()
+	r.Handoff = &Handoff{Stdout: -1, Stderr: -1, StartedAt: st.StartedAt}
+	var paused []*outputPipe
+	cancel = func() {
+		for _, o := range paused {
+			inheritFile(o.r, false)
+			o.unpause()
+		}
+	}
+	for _, o := range outs {
+		if !o.pause() {
+			continue
+		}
+		paused = append(paused, o)
+		fd, err := inheritFile(o.r, true)
+		switch {
+		case err != nil:
+			p.warnf("handoff of %s: %v", o.stream, err)
+		case o.stream == StreamStdout:
+			r.Handoff.Stdout = fd
+		default:
+			r.Handoff.Stderr = fd
+		}
+	}
+	return r, cancel, true
+}
+
+// Resume takes over the children handed over with Reexec by the previous
+// supervisor image. Call it in the new image after adding the processes and
+// before Run. Processes named in the records continue supervising the
+// running children instead of starting new ones, even if they have no
+// autostart: counters are restored from the records, readiness is not
+// checked again and the output is read from the inherited pipes. Children
+// without a process are left running, unsupervised, with the output
+// discarded; see Adopt. The handoff is cleared from the records.
+func (s *Supervisor) Resume(store Store) error {
+	records, err := store.List()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, r := range records {
+		if r.Handoff == nil {
+			continue
+		}
+		h := &handoff{runID: r.RunID, started: r.Handoff.StartedAt}
+		if r.Handoff.Stdout >= 0 {
+			h.stdout = os.NewFile(uintptr(r.Handoff.Stdout), r.Name+" stdout")
+		}
+		if r.Handoff.Stderr >= 0 {
+			h.stderr = os.NewFile(uintptr(r.Handoff.Stderr), r.Name+" stderr")
+		}
+		r.Handoff = nil
+		if err := store.Save(r); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", r.Name, err))
+		}
+		if h.proc, err = os.FindProcess(r.Pid); err != nil || !processAlive(r.Pid, r.RunID) {
+			if h.proc != nil {
+				// Reap the child if it has exited after the handoff
+				go h.proc.Wait()
+			}
+			h.close()
+			continue
+		}
+		p := s.Get(r.Name)
+		if p == nil {
+			h.discard()
+			continue
+		}
+		if err := p.Restore(r); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", r.Name, err))
+			h.discard()
+			continue
+		}
+		p.mu.Lock()
+		p.handoff = h
+		p.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+// close closes the inherited pipes
+func (h *handoff) close() {
+	for _, f := range []*os.File{h.stdout, h.stderr} {
+		if f != nil {
+			f.Close()
+		}
+	}
+}
+
+// discard drains the inherited pipes so that the child doesn't get EPIPE
+func (h *handoff) discard() {
+	for _, f := range []*os.File{h.stdout, h.stderr} {
+		if f != nil {
+			go func(f *os.File) {
+				io.Copy(io.Discard, f)
+				f.Close()
+			}(f)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+//go:build !windows
+
+package process
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// Reexec replaces the running supervisor with a new image, e.g. an upgraded
+// binary of itself, without stopping the children. Records of the running
+// children are saved to the store with the output pipes left open across
+// exec; the new image takes them over with Resume. Exec keeps the pid, so
+// the children stay children of the supervisor and their exit status is
+// known. Only the output is handed over: stdin, pipes to other processes,
+// readiness and resource watches start anew in the new image. Children that
+// are restarted while the handoff is in progress are not handed over. The
+// call returns only if the handoff fails, the children keep being
+// supervised then.
+func (s *Supervisor) Reexec(store Store, argv0 string, argv []string, envv []string) error {
+	s.mu.RLock()
+	run := s.run
+	s.mu.RUnlock()
+	if run == nil {
+		return ErrNotRunning
+	}
+	var cancels []func()
+	var names []string
+	cancel := func() {
+		for i, c := range cancels {
+			c()
+			if p := s.Get(names[i]); p != nil {
+				store.Save(p.Record(names[i]))
+			}
+		}
+	}
+	for _, name := range s.Names() {
+		p := s.Get(name)
+		if p == nil {
+			continue
+		}
+		r, c, ok := p.handOver(name)
+		if !ok {
+			continue
+		}
+		cancels, names = append(cancels, c), append(names, name)
+		if err := store.Save(r); err != nil {
+			cancel()
+			return fmt.Errorf("reexec: %s: %w", name, err)
+		}
+	}
+	err := syscall.Exec(argv0, argv, envv)
+	cancel()
+	return fmt.Errorf("reexec: %w", err)
+}
+
+// inheritFile sets whether the file descriptor is left open across exec
+func inheritFile(f *os.File, inherit bool) (fd int, err error) {
+	rc, err := f.SyscallConn()
+	if err != nil {
+		return -1, err
+	}
+	flags := syscall.FD_CLOEXEC
+	if inherit {
+		flags = 0
+	}
+	cerr := rc.Control(func(d uintptr) {
+		fd = int(d)
+		if _, _, e := syscall.Syscall(syscall.SYS_FCNTL, d, syscall.F_SETFD, uintptr(flags)); e != 0 {
+			err = e
+		}
+	})
+	if cerr != nil {
+		return -1, cerr
+	}
+	return
+}
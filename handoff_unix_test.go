@@ -0,0 +1,100 @@
+//go:build !windows
+
+package process_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andviro/process"
+)
+
+// TestReexecHelper is run as a child by TestReexec, first before and then
+// after the re-exec
+func TestReexecHelper(t *testing.T) {
+	path := os.Getenv("PROCESS_TEST_REEXEC")
+	if path == "" {
+		t.Skip("helper process")
+	}
+	store, err := process.OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s process.Supervisor
+	s.Add("ticker", &process.Process{Spec: process.Spec{
+		Cmd:         "/bin/sh",
+		Args:        []string{"-c", "while :; do echo tick $$; sleep 0.05; done"},
+		Stdout:      os.Stdout,
+		StopTimeout: 1000,
+	}})
+	resumed := os.Getenv("PROCESS_TEST_RESUMED") != ""
+	var pid int
+	if resumed {
+		r, err := store.Load("ticker")
+		if err != nil || r.Handoff == nil {
+			t.Fatalf("invalid record: %+v %v", r, err)
+		}
+		pid = r.Pid
+		if err := s.Resume(store); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.TODO())
+	done := make(chan map[string]process.RunResult)
+	go func() { done <- s.Run(ctx) }()
+	time.Sleep(300 * time.Millisecond)
+	if !resumed {
+		env := append(os.Environ(), "PROCESS_TEST_RESUMED=1")
+		err := s.Reexec(store, os.Args[0], os.Args, env)
+		t.Fatal(err)
+	}
+	if st := s.Get("ticker").Status(); st.Pid != pid || st.State != process.StateRunning {
+		t.Errorf("invalid status: %v %d, want pid %d", st.State, st.Pid, pid)
+	}
+	fmt.Println("resumed")
+	time.Sleep(300 * time.Millisecond)
+	cancel()
+	res := <-done
+	if res["ticker"].Reason != process.ReasonCanceled {
+		t.Errorf("invalid result: %+v", res["ticker"])
+	}
+}
+
+func TestReexec(t *testing.T) {
+	cmd := exec.Command(os.Args[0], "-test.run=^TestReexecHelper$", "-test.v")
+	cmd.Env = append(os.Environ(), "PROCESS_TEST_REEXEC="+filepath.Join(t.TempDir(), "store.json"))
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+	before, after, ok := strings.Cut(string(out), "resumed\n")
+	if !ok {
+		t.Fatalf("not resumed: %s", out)
+	}
+	ticks := strings.Fields(strings.Join(append(grep(before, "tick "), grep(after, "tick ")...), " "))
+	if len(grep(before, "tick ")) < 2 || len(grep(after, "tick ")) < 2 {
+		t.Fatalf("no ticks before or after re-exec: %s", out)
+	}
+	for i := 1; i < len(ticks); i += 2 {
+		if ticks[i] != ticks[1] {
+			t.Errorf("child restarted: %s", out)
+			break
+		}
+	}
+}
+
+// grep returns the lines of text with the prefix
+func grep(text, prefix string) (res []string) {
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(line, prefix) {
+			res = append(res, line)
+		}
+	}
+	return
+}
@@ -0,0 +1,18 @@
+package process
+
+import (
+	"errors"
+	"os"
+)
+
+// errNoReexec is reported by Reexec on Windows, which has no exec
+var errNoReexec = errors.New("re-exec is not supported on windows")
+
+// Reexec is not supported on Windows
+func (s *Supervisor) Reexec(store Store, argv0 string, argv []string, envv []string) error {
+	return errNoReexec
+}
+
+func inheritFile(f *os.File, inherit bool) (int, error) {
+	return -1, errNoReexec
+}
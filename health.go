@@ -0,0 +1,65 @@
+package process
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ProcessHealth is the health of a supervised process
+type ProcessHealth struct {
+	Name      string `json:"name"`      // Process name within the supervisor
+	State     State  `json:"state"`     // Current state
+	Critical  bool   `json:"critical"`  // Whether the process affects the supervisor health
+	Healthy   bool   `json:"healthy"`   // Process is running, ready and not marked unhealthy
+	Unhealthy string `json:"unhealthy"` // Output line that marked the process unhealthy
+}
+
+// Health returns the health of every process in order of addition
+func (s *Supervisor) Health() []ProcessHealth {
+	names := s.Names()
+	res := make([]ProcessHealth, len(names))
+	for i, name := range names {
+		p := s.Get(name)
+		st := p.Status()
+		res[i] = ProcessHealth{
+			Name:      name,
+			State:     st.State,
+			Critical:  p.Critical,
+			Healthy:   st.State == StateRunning && st.Unhealthy == "",
+			Unhealthy: st.Unhealthy,
+		}
+	}
+	return res
+}
+
+// Healthy reports whether all critical processes are running and ready
+func (s *Supervisor) Healthy() bool {
+	return healthy(s.Health())
+}
+
+func healthy(health []ProcessHealth) bool {
+	for _, h := range health {
+		if h.Critical && !h.Healthy {
+			return false
+		}
+	}
+	return true
+}
+
+// HealthHandler returns HTTP handler reporting the health of the processes
+// as JSON with status 200 if the supervisor is healthy and 503 otherwise
+func (s *Supervisor) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		health := s.Health()
+		code := http.StatusOK
+		if !healthy(health) {
+			code = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(struct {
+			Healthy   bool            `json:"healthy"`
+			Processes []ProcessHealth `json:"processes"`
+		}{code == http.StatusOK, health})
+	})
+}
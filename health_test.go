@@ -0,0 +1,72 @@
+package process_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/andviro/process"
+)
+
+func TestHealth(t *testing.T) {
+	var s process.Supervisor
+	app := &process.Process{Spec: process.Spec{
+		Cmd:          "/bin/sh",
+		Args:         []string{"-c", "sleep 0.2; echo ready; exec sleep 1"},
+		ReadyPattern: "ready",
+		StartTimeout: 1000,
+		StopTimeout:  1000,
+		Critical:     true,
+	}}
+	sidecar := &process.Process{Spec: process.Spec{
+		Cmd:          "/bin/false",
+		StartTimeout: 1000,
+	}}
+	s.Add("app", app)
+	s.Add("sidecar", sidecar)
+	srv := httptest.NewServer(s.HealthHandler())
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(ctx)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	if s.Healthy() {
+		t.Error("healthy before ready")
+	}
+	time.Sleep(300 * time.Millisecond)
+	if !s.Healthy() {
+		t.Errorf("unhealthy: %+v", s.Health())
+	}
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var body struct {
+		Healthy   bool
+		Processes []process.ProcessHealth
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || !body.Healthy || len(body.Processes) != 2 || body.Processes[1].Healthy {
+		t.Errorf("invalid response: %d %+v", resp.StatusCode, body)
+	}
+	cancel()
+	<-done
+	resp, err = http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("invalid status after stop: %d", resp.StatusCode)
+	}
+}
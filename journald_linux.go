@@ -0,0 +1,105 @@
+package process
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"sort"
+	"strings"
+)
+
+const journalSocket = "/run/systemd/journal/socket"
+
+// JournalSink writes child output and lifecycle events to the systemd
+// journal using the native protocol. Output lines have PRIORITY 6 for stdout
+// and 3 for stderr, and PROCESS_STREAM field set to the stream name.
+type JournalSink struct {
+	Name   string            // Stored in SYSLOG_IDENTIFIER and PROCESS_NAME fields
+	Fields map[string]string // Additional fields attached to every entry
+
+	conn *net.UnixConn
+}
+
+// DialJournal connects to the journal socket, empty socket path selects the
+// default one
+func DialJournal(socket, name string) (*JournalSink, error) {
+	if socket == "" {
+		socket = journalSocket
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+	return &JournalSink{Name: name, conn: conn}, nil
+}
+
+// Writer returns line-oriented writer for the output stream
+func (j *JournalSink) Writer(stream Stream) io.Writer {
+	priority := "6"
+	if stream == StreamStderr {
+		priority = "3"
+	}
+	return &lineWriter{fn: func(line []byte) {
+		j.send(string(line), "PRIORITY", priority, "PROCESS_STREAM", string(stream))
+	}}
+}
+
+// Event writes the state change
+func (j *JournalSink) Event(e Event) {
+	priority := "5"
+	msg := e.Prev.String() + " -> " + e.State.String()
+	fields := []string{"PROCESS_STATE", e.State.String(), "PROCESS_RUN_ID", e.RunID}
+	if e.Err != nil {
+		priority = "4"
+		msg += ": " + e.Err.Error()
+		fields = append(fields, "PROCESS_ERROR", e.Err.Error())
+	}
+	if e.State == StateFailed || e.State == StatePreflightFailed {
+		priority = "3"
+	}
+	j.send(msg, append(fields, "PRIORITY", priority)...)
+}
+
+// Close closes the journal socket
+func (j *JournalSink) Close() error {
+	return j.conn.Close()
+}
+
+func (j *JournalSink) send(msg string, kv ...string) error {
+	var buf bytes.Buffer
+	writeJournalField(&buf, "MESSAGE", msg)
+	if j.Name != "" {
+		writeJournalField(&buf, "SYSLOG_IDENTIFIER", j.Name)
+		writeJournalField(&buf, "PROCESS_NAME", j.Name)
+	}
+	for i := 0; i+1 < len(kv); i += 2 {
+		writeJournalField(&buf, kv[i], kv[i+1])
+	}
+	keys := make([]string, 0, len(j.Fields))
+	for k := range j.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		writeJournalField(&buf, k, j.Fields[k])
+	}
+	_, err := j.conn.Write(buf.Bytes())
+	return err
+}
+
+// writeJournalField serializes the field, values containing newlines use
+// the length-prefixed binary form
+func writeJournalField(buf *bytes.Buffer, key, value string) {
+	buf.WriteString(key)
+	if !strings.ContainsRune(value, '\n') {
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+	buf.WriteByte('\n')
+	binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
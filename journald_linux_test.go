@@ -0,0 +1,53 @@
+package process_test
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andviro/process"
+)
+
+func TestJournalSink(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "journal.socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	defer conn.Close()
+	sink, err := process.DialJournal(socket, "worker")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	defer sink.Close()
+	sink.Fields = map[string]string{"DEPLOYMENT": "test"}
+
+	p := &process.Process{Spec: process.Spec{
+		Cmd:  "/bin/sh",
+		Args: []string{"-c", "echo oops >&2"},
+		Sink: sink,
+	}}
+	<-p.Run(context.TODO())
+
+	buf := make([]byte, 4096)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			t.Fatalf("message not received: %+v", err)
+		}
+		entry := string(buf[:n])
+		if !strings.Contains(entry, "PROCESS_STREAM=stderr\n") {
+			continue
+		}
+		for _, field := range []string{"MESSAGE=oops\n", "PRIORITY=3\n", "SYSLOG_IDENTIFIER=worker\n", "DEPLOYMENT=test\n"} {
+			if !strings.Contains(entry, field) {
+				t.Errorf("field %q not found in %q", field, entry)
+			}
+		}
+		break
+	}
+}
@@ -0,0 +1,87 @@
+package process
+
+import (
+	"fmt"
+	"io"
+)
+
+// Output limit actions
+const (
+	LimitTruncate = "truncate" // Discard output exceeding the limit
+	LimitRotate   = "rotate"   // Rotate the output writer implementing Rotator and reset the counter
+	LimitKill     = "kill"     // Stop the process as failed
+)
+
+// Rotator is implemented by output writers that can start over, e.g. by
+// switching to a new file
+type Rotator interface {
+	Rotate() error
+}
+
+// limitWriter enforces the byte limit on a single stream of one start attempt
+type limitWriter struct {
+	w        io.Writer
+	rotators []Rotator
+	limit    int64
+	written  int64
+	onKill   func()
+}
+
+func (lw *limitWriter) Write(b []byte) (n int, err error) {
+	n = len(b)
+	if lw.written+int64(n) > lw.limit && len(lw.rotators) > 0 {
+		for _, r := range lw.rotators {
+			if err = r.Rotate(); err != nil {
+				return 0, err
+			}
+		}
+		lw.written = 0
+	}
+	if avail := lw.limit - lw.written; int64(len(b)) > avail {
+		if lw.onKill != nil {
+			lw.onKill()
+		}
+		if avail < 0 {
+			avail = 0
+		}
+		b = b[:avail]
+	}
+	lw.written += int64(n)
+	if len(b) == 0 || lw.w == nil {
+		return
+	}
+	if _, err = lw.w.Write(b); err != nil {
+		return 0, err
+	}
+	return
+}
+
+// limitWriter wraps w with the stream limit, rotating the destinations on
+// LimitRotate
+func (p *Process) limitWriter(stream Stream, w io.Writer, rotators []Rotator) io.Writer {
+	limit := p.StdoutLimit
+	if stream == StreamStderr {
+		limit = p.StderrLimit
+	}
+	if limit <= 0 {
+		return w
+	}
+	lw := &limitWriter{w: w, limit: limit}
+	action := p.OutputLimitAction
+	if a := p.streamPolicy(stream).LimitAction; a != "" {
+		action = a
+	}
+	switch action {
+	case LimitRotate:
+		lw.rotators = rotators
+	case LimitKill:
+		reqs := p.requests
+		lw.onKill = func() {
+			reqs.send(request{
+				err: ErrOutputLimit,
+				msg: fmt.Sprintf("%s exceeded %d bytes, stopping", stream, limit),
+			})
+		}
+	}
+	return lw
+}
@@ -0,0 +1,102 @@
+package process_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andviro/process"
+)
+
+type rotatingBuffer struct {
+	syncBuffer
+	rotations int
+}
+
+func (b *rotatingBuffer) Rotate() error {
+	b.rotations++
+	b.Write([]byte("|"))
+	return nil
+}
+
+func TestOutputLimitTruncate(t *testing.T) {
+	var stdout, stderr syncBuffer
+	p := &process.Process{Spec: process.Spec{
+		Cmd:         "/bin/sh",
+		Args:        []string{"-c", "echo 0123456789; echo abcdef; echo err >&2"},
+		Stdout:      &stdout,
+		Stderr:      &stderr,
+		StdoutLimit: 8,
+	}}
+	if res := <-p.Run(context.TODO()); res.Err != nil {
+		t.Fatalf("%+v", res.Err)
+	}
+	if out := stdout.String(); out != "01234567" {
+		t.Errorf("invalid stdout: %q", out)
+	}
+	if out := stderr.String(); !strings.Contains(out, "err\n") {
+		t.Errorf("invalid stderr: %q", out)
+	}
+}
+
+func TestOutputLimitRotate(t *testing.T) {
+	var stdout rotatingBuffer
+	p := &process.Process{Spec: process.Spec{
+		Cmd:               "/bin/sh",
+		Args:              []string{"-c", "echo 0123; sleep 0.05; echo 4567; sleep 0.05; echo 89"},
+		Stdout:            &stdout,
+		StdoutLimit:       6,
+		StartTimeout:      1000,
+		OutputLimitAction: process.LimitRotate,
+	}}
+	<-p.Run(context.TODO())
+	if out := stdout.String(); out != "0123\n|4567\n|89\n" || stdout.rotations != 2 {
+		t.Errorf("invalid output: %q", out)
+	}
+}
+
+func TestOutputLimitRotateWrapped(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		spec process.Spec
+	}{
+		{"buffered", process.Spec{OutputBuffer: 64}},
+		{"formatted", process.Spec{LineFormat: &process.LineFormat{Template: "{{.Line}}"}}},
+	} {
+		stdout := new(rotatingBuffer)
+		spec := tc.spec
+		spec.Cmd, spec.Args = "/bin/sh", []string{"-c", "echo 0123; sleep 0.05; echo 4567; sleep 0.05; echo 89"}
+		spec.Stdout, spec.StdoutLimit, spec.OutputLimitAction = stdout, 6, process.LimitRotate
+		spec.StartTimeout = 1000
+		<-(&process.Process{Spec: spec}).Run(context.TODO())
+		deadline := time.Now().Add(time.Second)
+		for stdout.String() != "0123\n|4567\n|89\n" && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if out := stdout.String(); out != "0123\n|4567\n|89\n" || stdout.rotations != 2 {
+			t.Errorf("%s: invalid output: %q", tc.name, out)
+		}
+	}
+}
+
+func TestOutputLimitKill(t *testing.T) {
+	p := &process.Process{Spec: process.Spec{
+		Cmd:               "/bin/sh",
+		Args:              []string{"-c", "exec yes"},
+		Stdout:            new(syncBuffer),
+		StdoutLimit:       1000,
+		StartTimeout:      50,
+		StopTimeout:       1000,
+		OutputLimitAction: process.LimitKill,
+	}}
+	select {
+	case res := <-p.Run(context.TODO()):
+		if !errors.Is(res.Err, process.ErrOutputLimit) || res.State != process.StateStopped {
+			t.Errorf("invalid result: %+v", res)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("process not stopped")
+	}
+}
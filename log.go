@@ -0,0 +1,75 @@
+package process
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Log levels
+const (
+	LogError = "error" // Failures only
+	LogWarn  = "warn"  // Failures and problems that don't stop the process, e.g. failed diagnostics
+	LogInfo  = "info"  // Lifecycle messages, the default
+	LogDebug = "debug" // Everything
+	LogNone  = "none"  // No messages
+)
+
+type logLevel int
+
+const (
+	levelNone logLevel = iota
+	levelError
+	levelWarn
+	levelInfo
+	levelDebug
+)
+
+var logLevels = map[string]logLevel{
+	"":       levelInfo,
+	LogError: levelError,
+	LogWarn:  levelWarn,
+	LogInfo:  levelInfo,
+	LogDebug: levelDebug,
+	LogNone:  levelNone,
+}
+
+const logTimeLayout = "2006-01-02 15:04:05.000000 -0700 MST"
+
+var logBuffers = sync.Pool{New: func() interface{} { return new([]byte) }}
+
+func (p *Process) logf(format string, args ...interface{}) {
+	p.log(levelInfo, format, args)
+}
+
+func (p *Process) errorf(format string, args ...interface{}) {
+	p.log(levelError, format, args)
+}
+
+func (p *Process) warnf(format string, args ...interface{}) {
+	p.log(levelWarn, format, args)
+}
+
+func (p *Process) debugf(format string, args ...interface{}) {
+	p.log(levelDebug, format, args)
+}
+
+// log writes the message to Stderr. Nothing is formatted unless the level is
+// enabled.
+func (p *Process) log(level logLevel, format string, args []interface{}) {
+	if p.Stderr == nil || logLevels[p.LogLevel] < level {
+		return
+	}
+	buf := logBuffers.Get().(*[]byte)
+	b := time.Now().AppendFormat((*buf)[:0], logTimeLayout)
+	b = append(b, ' ')
+	b = append(b, p.procName()...)
+	b = append(b, '[')
+	b = append(b, p.RunID...)
+	b = append(b, "]: "...)
+	b = fmt.Appendf(b, format, args...)
+	b = append(b, '\n')
+	p.Stderr.Write(b)
+	*buf = b
+	logBuffers.Put(buf)
+}
@@ -0,0 +1,116 @@
+package process
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Phase is a part of the process lifecycle measured by Metrics
+type Phase string
+
+// Measured lifecycle phases
+const (
+	PhaseStart   Phase = "start"   // Time spent starting, until running or giving up
+	PhaseStop    Phase = "stop"    // Time from the stop signal until the child exited, including kill
+	PhaseBackoff Phase = "backoff" // Time spent waiting before another start attempt
+	PhaseRestart Phase = "restart" // Time spent waiting before restart
+	PhaseDrain   Phase = "drain"   // Time spent draining
+)
+
+// Metrics receives measurements of the process lifecycle. Methods are
+// called synchronously on state transitions and should not block.
+type Metrics interface {
+	// ObservePhase records the duration of the phase of the named process
+	ObservePhase(name string, phase Phase, d time.Duration)
+}
+
+// observePhase reports the phase finished by the transition from prev
+func (p *Process) observePhase(prev State, elapsed time.Duration, now time.Time) {
+	name := p.procName()
+	switch prev {
+	case StateStarting:
+		p.Metrics.ObservePhase(name, PhaseStart, elapsed)
+	case StateBackoff:
+		p.Metrics.ObservePhase(name, PhaseBackoff, elapsed)
+	case StateRestarting:
+		p.Metrics.ObservePhase(name, PhaseRestart, elapsed)
+	case StateDraining:
+		p.Metrics.ObservePhase(name, PhaseDrain, elapsed)
+	}
+	stop := p.State == StateStopping || p.State == StateKilling
+	switch {
+	case stop && p.stopSince.IsZero():
+		p.stopSince = now
+	case !stop && !p.stopSince.IsZero():
+		p.Metrics.ObservePhase(name, PhaseStop, now.Sub(p.stopSince))
+		p.stopSince = time.Time{}
+	}
+}
+
+// DefaultBuckets are upper bounds of Histograms buckets used by default
+var DefaultBuckets = []time.Duration{
+	10 * time.Millisecond, 50 * time.Millisecond, 100 * time.Millisecond,
+	500 * time.Millisecond, time.Second, 5 * time.Second, 10 * time.Second,
+	30 * time.Second, time.Minute,
+}
+
+// Histogram is a snapshot of phase durations distribution
+type Histogram struct {
+	Buckets []time.Duration `json:"buckets"` // Upper bounds of the buckets
+	Counts  []uint64        `json:"counts"`  // Number of observations per bucket, the last one is unbounded
+	Count   uint64          `json:"count"`   // Total number of observations
+	Sum     time.Duration   `json:"sum"`     // Sum of the observed durations
+}
+
+// Histograms is Metrics keeping in-memory histograms of phase durations per
+// process name
+type Histograms struct {
+	buckets []time.Duration
+	mu      sync.Mutex
+	hists   map[histogramKey]*Histogram
+}
+
+type histogramKey struct {
+	name  string
+	phase Phase
+}
+
+// NewHistograms creates histograms with the bucket upper bounds,
+// DefaultBuckets if none are given
+func NewHistograms(buckets ...time.Duration) *Histograms {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]time.Duration(nil), buckets...)
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i] < buckets[j] })
+	return &Histograms{buckets: buckets, hists: make(map[histogramKey]*Histogram)}
+}
+
+// ObservePhase adds the duration to the histogram of the process phase
+func (h *Histograms) ObservePhase(name string, phase Phase, d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	key := histogramKey{name, phase}
+	hist, ok := h.hists[key]
+	if !ok {
+		hist = &Histogram{Buckets: h.buckets, Counts: make([]uint64, len(h.buckets)+1)}
+		h.hists[key] = hist
+	}
+	hist.Counts[sort.Search(len(h.buckets), func(i int) bool { return d <= h.buckets[i] })]++
+	hist.Count++
+	hist.Sum += d
+}
+
+// Histogram returns a snapshot of the process phase histogram
+func (h *Histograms) Histogram(name string, phase Phase) Histogram {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	hist, ok := h.hists[histogramKey{name, phase}]
+	if !ok {
+		return Histogram{Buckets: h.buckets, Counts: make([]uint64, len(h.buckets)+1)}
+	}
+	res := *hist
+	res.Counts = append([]uint64(nil), hist.Counts...)
+	return res
+}
@@ -0,0 +1,38 @@
+package process_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/andviro/process"
+)
+
+func TestHistograms(t *testing.T) {
+	m := process.NewHistograms(50*time.Millisecond, time.Second)
+	spec := process.Spec{
+		Name:         "sleeper",
+		Cmd:          "/bin/sleep",
+		Args:         []string{"3"},
+		StartTimeout: 100,
+		StopTimeout:  1000,
+		Metrics:      m,
+	}
+	h := spec.Run(context.TODO())
+	for e := range h.Events() {
+		if e.State == process.StateRunning {
+			h.Stop()
+		}
+	}
+	start := m.Histogram("sleeper", process.PhaseStart)
+	if start.Count != 1 || start.Counts[1] != 1 || start.Sum < 100*time.Millisecond {
+		t.Errorf("invalid start histogram: %+v", start)
+	}
+	stop := m.Histogram("sleeper", process.PhaseStop)
+	if stop.Count != 1 || stop.Counts[0]+stop.Counts[1] != 1 {
+		t.Errorf("invalid stop histogram: %+v", stop)
+	}
+	if backoff := m.Histogram("sleeper", process.PhaseBackoff); backoff.Count != 0 || len(backoff.Counts) != 3 {
+		t.Errorf("invalid backoff histogram: %+v", backoff)
+	}
+}
@@ -0,0 +1,205 @@
+package process
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// Buffering policies of MultiSink members
+const (
+	BufferBlock = "block" // Wait for free space in the member buffer
+	BufferDrop  = "drop"  // Discard new data when the member buffer is full
+
+	BufferDropOldest = "drop-oldest" // Discard the oldest buffered data to make room for new one
+)
+
+const defaultSinkBuffer = 256
+
+// SinkStats reports delivery problems of a MultiSink member
+type SinkStats struct {
+	Dropped uint64 // Number of discarded writes and events
+	Errors  uint64 // Number of failed writes
+}
+
+// MultiSink tees output and events to several sinks. Every member is served
+// by its own goroutine through a bounded buffer, so that slow or failing
+// sinks don't block or break the others.
+type MultiSink struct {
+	mu      sync.RWMutex
+	members []*sinkMember
+	closed  bool
+	wg      sync.WaitGroup
+}
+
+type sinkItem struct {
+	w     io.Writer
+	data  []byte
+	event *Event
+	flush bool // Flush w after the data written before
+}
+
+type sinkMember struct {
+	sink    Sink
+	policy  string
+	items   chan sinkItem
+	dropped uint64
+	errors  uint64
+}
+
+// NewMultiSink creates a sink delivering to all the sinks with default
+// buffer size and BufferDrop policy
+func NewMultiSink(sinks ...Sink) *MultiSink {
+	res := new(MultiSink)
+	for _, s := range sinks {
+		res.Add(s, defaultSinkBuffer, BufferDrop)
+	}
+	return res
+}
+
+// Add appends a member with the buffer of the given number of writes and events
+func (m *MultiSink) Add(s Sink, size int, policy string) {
+	member := &sinkMember{sink: s, policy: policy, items: make(chan sinkItem, size)}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.members = append(m.members, member)
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		member.serve()
+	}()
+}
+
+// Writer returns writer delivering the stream to all members
+func (m *MultiSink) Writer(stream Stream) io.Writer {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var w multiSinkWriter
+	w.m = m
+	for _, member := range m.members {
+		if dest := member.sink.Writer(stream); dest != nil {
+			w.members = append(w.members, member)
+			w.dests = append(w.dests, dest)
+		}
+	}
+	return &w
+}
+
+// Event delivers the event to all members
+func (m *MultiSink) Event(e Event) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.closed {
+		return
+	}
+	for _, member := range m.members {
+		member.enqueue(sinkItem{event: &e})
+	}
+}
+
+// Stats returns delivery statistics of members in order of addition
+func (m *MultiSink) Stats() []SinkStats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	res := make([]SinkStats, len(m.members))
+	for i, member := range m.members {
+		res[i] = SinkStats{
+			Dropped: atomic.LoadUint64(&member.dropped),
+			Errors:  atomic.LoadUint64(&member.errors),
+		}
+	}
+	return res
+}
+
+// Close delivers buffered data and stops member goroutines. Member sinks are
+// not closed.
+func (m *MultiSink) Close() error {
+	m.mu.Lock()
+	if !m.closed {
+		m.closed = true
+		for _, member := range m.members {
+			close(member.items)
+		}
+	}
+	m.mu.Unlock()
+	m.wg.Wait()
+	return nil
+}
+
+type multiSinkWriter struct {
+	m       *MultiSink
+	members []*sinkMember
+	dests   []io.Writer
+}
+
+func (w *multiSinkWriter) Write(b []byte) (int, error) {
+	data := append([]byte(nil), b...)
+	w.m.mu.RLock()
+	defer w.m.mu.RUnlock()
+	if w.m.closed {
+		return len(b), nil
+	}
+	for i, member := range w.members {
+		member.enqueue(sinkItem{w: w.dests[i], data: data})
+	}
+	return len(b), nil
+}
+
+// Flush queues flushing of the member writers holding incomplete lines
+func (w *multiSinkWriter) Flush() error {
+	w.m.mu.RLock()
+	defer w.m.mu.RUnlock()
+	if w.m.closed {
+		return nil
+	}
+	for i, member := range w.members {
+		if _, ok := w.dests[i].(flusher); ok {
+			member.enqueue(sinkItem{w: w.dests[i], flush: true})
+		}
+	}
+	return nil
+}
+
+func (m *sinkMember) enqueue(item sinkItem) {
+	switch m.policy {
+	case BufferBlock:
+		m.items <- item
+		return
+	case BufferDropOldest:
+		// The buffer works as a ring: a slow member only loses the
+		// backlog and then catches up with the latest data
+		for {
+			select {
+			case m.items <- item:
+				return
+			default:
+			}
+			select {
+			case <-m.items:
+				atomic.AddUint64(&m.dropped, 1)
+			default:
+			}
+		}
+	}
+	select {
+	case m.items <- item:
+	default:
+		atomic.AddUint64(&m.dropped, 1)
+	}
+}
+
+func (m *sinkMember) serve() {
+	for item := range m.items {
+		if item.event != nil {
+			m.sink.Event(*item.event)
+			continue
+		}
+		if item.flush {
+			item.w.(flusher).Flush()
+			continue
+		}
+		if _, err := item.w.Write(item.data); err != nil {
+			atomic.AddUint64(&m.errors, 1)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package process_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/andviro/process"
+)
+
+type testSink struct {
+	out    syncBuffer
+	events []process.Event
+	block  chan struct{}
+	fail   bool
+}
+
+func (s *testSink) Writer(stream process.Stream) io.Writer {
+	if stream != process.StreamStdout {
+		return nil
+	}
+	return s
+}
+
+func (s *testSink) Write(b []byte) (int, error) {
+	if s.block != nil {
+		<-s.block
+	}
+	if s.fail {
+		return 0, errors.New("sink failed")
+	}
+	return s.out.Write(b)
+}
+
+func (s *testSink) Event(e process.Event) {
+	s.events = append(s.events, e)
+}
+
+func TestMultiSink(t *testing.T) {
+	good, failing, slow := new(testSink), &testSink{fail: true}, &testSink{block: make(chan struct{})}
+	m := process.NewMultiSink(good, failing)
+	m.Add(slow, 1, process.BufferDrop)
+
+	p := &process.Process{Spec: process.Spec{
+		Cmd:          "/bin/sh",
+		Args:         []string{"-c", "for i in 1 2 3 4 5; do echo line $i; sleep 0.01; done"},
+		StartTimeout: 10,
+		Sink:         m,
+	}}
+	<-p.Run(context.TODO())
+	close(slow.block)
+	m.Close()
+
+	if out := good.out.String(); strings.Count(out, "line") != 5 {
+		t.Errorf("invalid output: %q", out)
+	}
+	if len(good.events) != 3 || good.events[2].State != process.StateStopped {
+		t.Errorf("invalid events: %+v", good.events)
+	}
+	stats := m.Stats()
+	if stats[0] != (process.SinkStats{}) {
+		t.Errorf("invalid stats of good sink: %+v", stats[0])
+	}
+	// Lines may be coalesced into a single write under load
+	if stats[1].Errors == 0 || stats[1].Errors > 5 {
+		t.Errorf("invalid stats of failing sink: %+v", stats[1])
+	}
+	if stats[2].Dropped == 0 {
+		t.Errorf("invalid stats of slow sink: %+v", stats[2])
+	}
+}
+
+func TestMultiSinkDropOldest(t *testing.T) {
+	slow := &testSink{block: make(chan struct{})}
+	m := new(process.MultiSink)
+	m.Add(slow, 2, process.BufferDropOldest)
+	w := m.Writer(process.StreamStdout)
+	for i := 1; i <= 10; i++ {
+		fmt.Fprintf(w, "%d\n", i)
+	}
+	close(slow.block)
+	m.Close()
+	out := slow.out.String()
+	// The member may have taken one early write before it blocked
+	if !strings.HasSuffix(out, "9\n10\n") || strings.Count(out, "\n") > 3 {
+		t.Errorf("invalid output: %q", out)
+	}
+	if dropped := m.Stats()[0].Dropped; dropped < 7 {
+		t.Errorf("invalid dropped count: %d", dropped)
+	}
+}
+
+type consoleSink struct {
+	out syncBuffer
+	c   *process.Console
+}
+
+func (s *consoleSink) Writer(stream process.Stream) io.Writer {
+	if s.c == nil {
+		s.c = process.NewConsole(&s.out)
+	}
+	return s.c.Writer(string(stream))
+}
+
+func (s *consoleSink) Event(process.Event) {}
+
+func TestMultiSinkFlush(t *testing.T) {
+	sink := new(consoleSink)
+	m := process.NewMultiSink(sink)
+	p := &process.Process{Spec: process.Spec{Cmd: "/bin/sh", Args: []string{"-c", "printf foo"}, Sink: m}}
+	<-p.Run(context.TODO())
+	m.Close()
+	if out := sink.out.String(); out != "stdout | foo\n" {
+		t.Errorf("invalid output: %q", out)
+	}
+}
@@ -0,0 +1,80 @@
+//go:build otel
+
+package process
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// OTelMetrics records process metrics with OpenTelemetry instruments. It is
+// both Sink and Metrics. It's only built with the "otel" build tag, so that
+// the package doesn't depend on OpenTelemetry otherwise.
+type OTelMetrics struct {
+	restarts metric.Int64Counter
+	failures metric.Int64Counter
+	states   metric.Int64UpDownCounter
+	uptime   metric.Float64Counter
+	phases   metric.Float64Histogram
+}
+
+// NewOTelMetrics creates the instruments with a meter of the provider
+func NewOTelMetrics(mp metric.MeterProvider) (m *OTelMetrics, err error) {
+	meter := mp.Meter("github.com/andviro/process")
+	m = new(OTelMetrics)
+	if m.restarts, err = meter.Int64Counter("process.restarts",
+		metric.WithDescription("Number of process restarts")); err != nil {
+		return nil, err
+	}
+	if m.failures, err = meter.Int64Counter("process.failures",
+		metric.WithDescription("Number of processes given up")); err != nil {
+		return nil, err
+	}
+	if m.states, err = meter.Int64UpDownCounter("process.state",
+		metric.WithDescription("Processes in the state, set to 1 for the current state of every process")); err != nil {
+		return nil, err
+	}
+	if m.uptime, err = meter.Float64Counter("process.uptime",
+		metric.WithDescription("Time spent running"), metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+	if m.phases, err = meter.Float64Histogram("process.phase.duration",
+		metric.WithDescription("Duration of lifecycle phases"), metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Writer returns nil, output is not recorded
+func (m *OTelMetrics) Writer(stream Stream) io.Writer {
+	return nil
+}
+
+// Event updates the counters and state gauges
+func (m *OTelMetrics) Event(e Event) {
+	ctx := context.Background()
+	name := attribute.String("process", e.Name)
+	switch e.State {
+	case StateRestarting:
+		m.restarts.Add(ctx, 1, metric.WithAttributes(name))
+	case StateFailed, StatePreflightFailed:
+		m.failures.Add(ctx, 1, metric.WithAttributes(name))
+	}
+	if e.Prev == StateRunning {
+		m.uptime.Add(ctx, e.Elapsed.Seconds(), metric.WithAttributes(name))
+	}
+	if e.Prev != StateIdle {
+		m.states.Add(ctx, -1, metric.WithAttributes(name, attribute.String("state", e.Prev.String())))
+	}
+	m.states.Add(ctx, 1, metric.WithAttributes(name, attribute.String("state", e.State.String())))
+}
+
+// ObservePhase records the phase duration in the histogram
+func (m *OTelMetrics) ObservePhase(name string, phase Phase, d time.Duration) {
+	m.phases.Record(context.Background(), d.Seconds(), metric.WithAttributes(
+		attribute.String("process", name), attribute.String("phase", string(phase))))
+}
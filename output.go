@@ -0,0 +1,220 @@
+package process
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"sync"
+	"text/template"
+)
+
+// Stream identifies child output stream
+type Stream string
+
+// Output streams
+const (
+	StreamStdout Stream = "stdout"
+	StreamStderr Stream = "stderr"
+)
+
+// maxLineLength limits the line buffer, longer lines are split
+const maxLineLength = 64 * 1024
+
+// lineWriter passes output through to the underlying writer and calls fn for
+// every complete line without the trailing newline. It is safe for concurrent
+// use, e.g. when the same writer is used for the child stderr and package
+// messages; fn calls are serialized.
+type lineWriter struct {
+	w  io.Writer
+	fn func(line []byte)
+
+	mu     sync.Mutex
+	buf    []byte
+	pooled *[]byte // Pool entry holding buf
+}
+
+func (lw *lineWriter) Write(b []byte) (n int, err error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	n = len(b)
+	if lw.w != nil {
+		if n, err = lw.w.Write(b); err != nil {
+			return
+		}
+	}
+	// Complete lines are passed straight from b, only an incomplete tail is
+	// kept in the buffer until the next write
+	data, buffered := b, lw.pooled != nil
+	if buffered {
+		lw.buf = append(lw.buf, b...)
+		data = lw.buf
+	}
+	start := 0
+	for {
+		i := bytes.IndexByte(data[start:], '\n')
+		if i < 0 {
+			break
+		}
+		lw.fn(bytes.TrimSuffix(data[start:start+i], []byte{'\r'}))
+		start += i + 1
+	}
+	if len(data)-start > maxLineLength {
+		lw.fn(data[start:])
+		start = len(data)
+	}
+	switch rest := data[start:]; {
+	case len(rest) == 0:
+		if buffered {
+			*lw.pooled = lw.buf[:0]
+			putLineBuffer(lw.pooled)
+			lw.buf, lw.pooled = nil, nil
+		}
+	case buffered:
+		lw.buf = lw.buf[:copy(lw.buf, rest)]
+	default:
+		lw.pooled = lineBuffers.Get().(*[]byte)
+		lw.buf = append((*lw.pooled)[:0], rest...)
+	}
+	return
+}
+
+// Flush passes the incomplete last line to fn
+func (lw *lineWriter) Flush() error {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	if lw.pooled == nil {
+		return nil
+	}
+	lw.fn(bytes.TrimSuffix(lw.buf, []byte{'\r'}))
+	*lw.pooled = lw.buf[:0]
+	putLineBuffer(lw.pooled)
+	lw.buf, lw.pooled = nil, nil
+	return nil
+}
+
+// flusher is implemented by writers that hold an incomplete last line, such
+// as the line-oriented writers of the package
+type flusher interface {
+	Flush() error
+}
+
+// lineBuffers hold incomplete lines of all writers, so that idle children
+// don't keep buffers allocated
+var lineBuffers = sync.Pool{New: func() interface{} {
+	b := make([]byte, 0, 4096)
+	return &b
+}}
+
+func putLineBuffer(b *[]byte) {
+	// Buffers grown by huge writes are left to the garbage collector
+	if cap(*b) <= 2*maxLineLength {
+		lineBuffers.Put(b)
+	}
+}
+
+// outputs returns writers for the child output streams
+func (p *Process) outputs(readyPattern *regexp.Regexp, format *template.Template) (stdout, stderr io.Writer) {
+	var matchers []func([]byte)
+	if readyPattern != nil {
+		ready, once := p.ready, new(sync.Once)
+		matchers = append(matchers, func(line []byte) {
+			if readyPattern.Match(line) {
+				once.Do(func() { close(ready) })
+			}
+		})
+	}
+	p.asyncs, p.flushers = nil, nil
+	user, rotator := p.userWriter(StreamStdout, p.Stdout, format)
+	stdout = p.streamWriter(StreamStdout, user, rotator, matchers...)
+	user, rotator = p.userWriter(StreamStderr, p.Stderr, format)
+	stderr = p.streamWriter(StreamStderr, user, rotator)
+	return
+}
+
+// userWriter wraps the Stdout or Stderr writer, nil if the stream is
+// discarded. The rotator is set if the writer implements Rotator, rotations
+// are queued with the output if it is buffered.
+func (p *Process) userWriter(stream Stream, w io.Writer, format *template.Template) (io.Writer, Rotator) {
+	if p.streamPolicy(stream).Discard {
+		return nil, nil
+	}
+	rotator, _ := w.(Rotator)
+	out := p.asyncOutput(stream, w)
+	if aw, ok := out.(*asyncWriter); ok {
+		if rotator != nil {
+			rotator = aw
+		}
+	} else if f, ok := w.(flusher); ok {
+		// Asynchronous writers flush after the buffered output
+		p.flushers = append(p.flushers, f)
+	}
+	return p.formatWriter(stream, out, format), rotator
+}
+
+func (p *Process) streamWriter(stream Stream, user io.Writer, rotator Rotator, matchers ...func([]byte)) io.Writer {
+	policy := p.streamPolicy(stream)
+	w := user
+	var rotators []Rotator
+	if rotator != nil {
+		rotators = append(rotators, rotator)
+	}
+	if p.Sink != nil && !policy.Discard {
+		sink := p.Sink.Writer(stream)
+		if f, ok := sink.(flusher); ok {
+			p.flushers = append(p.flushers, f)
+		}
+		if r, ok := sink.(Rotator); ok {
+			rotators = append(rotators, r)
+		}
+		w = tee(w, sink)
+	}
+	w = p.pipesWriter(stream, w)
+	if p.capture != nil && !policy.Discard && !policy.NoCapture {
+		w = tee(w, p.capture)
+	}
+	w = p.limitWriter(stream, w, rotators)
+	reqs := p.requests
+	for _, t := range p.triggers.forStream(stream) {
+		t := t
+		matchers = append(matchers, func(line []byte) {
+			if t.re.Match(line) {
+				p.trigger(reqs, t.Trigger, line)
+			}
+		})
+	}
+	if len(matchers) == 0 {
+		return w
+	}
+	lw := &lineWriter{w: w, fn: func(line []byte) {
+		for _, match := range matchers {
+			match(line)
+		}
+	}}
+	p.flushers = append(p.flushers, lw)
+	return lw
+}
+
+// StreamPolicy overrides handling of a single output stream
+type StreamPolicy struct {
+	Discard     bool   `json:"discard"`     // Don't pass the stream to the writer, Sink and capture; pipes, ready pattern and triggers still see it
+	NoCapture   bool   `json:"noCapture"`   // Leave the stream out of CaptureOutput
+	LimitAction string `json:"limitAction"` // Overrides OutputLimitAction for the stream
+}
+
+func (s Spec) streamPolicy(stream Stream) StreamPolicy {
+	policy := s.StdoutPolicy
+	if stream == StreamStderr {
+		policy = s.StderrPolicy
+	}
+	if policy == nil {
+		return StreamPolicy{}
+	}
+	return *policy
+}
+
+func compileReadyPattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile(pattern)
+}
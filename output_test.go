@@ -0,0 +1,162 @@
+package process_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andviro/process"
+)
+
+func TestReadyPattern(t *testing.T) {
+	spec := process.Spec{
+		Cmd:          "/bin/sh",
+		Args:         []string{"-c", "sleep 0.2; echo 'Listening on :8080'; exec sleep 5"},
+		StartTimeout: 3000,
+		StopTimeout:  1000,
+		ReadyPattern: `^Listening on :\d+$`,
+	}
+	h := spec.Run(context.TODO())
+	for e := range h.Events() {
+		if e.State != process.StateRunning {
+			continue
+		}
+		if e.Elapsed < 200*time.Millisecond || e.Elapsed > time.Second {
+			t.Errorf("invalid start duration: %v", e.Elapsed)
+		}
+		h.Stop()
+	}
+	if res := h.Wait(); res.State != process.StateStopped {
+		t.Errorf("invalid result: %+v", res)
+	}
+}
+
+func TestNotReady(t *testing.T) {
+	p := &process.Process{Spec: process.Spec{
+		Cmd:              "/bin/sh",
+		Args:             []string{"-c", "echo starting; exec sleep 5"},
+		StartTimeout:     100,
+		StopTimeout:      1000,
+		BackoffTimeout:   10,
+		MaxStartAttempts: 1,
+		ReadyPattern:     "ready",
+	}}
+	select {
+	case res := <-p.Run(context.TODO()):
+		if res.Attempts != 2 || res.State != process.StateFailed {
+			t.Errorf("invalid result: %+v", res)
+		}
+		if !errors.Is(res.Err, process.ErrMaxStartAttempts) || !errors.Is(res.Err, process.ErrNotReady) {
+			t.Errorf("%#v", res.Err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("process not stopped")
+	}
+}
+
+func TestCaptureOutput(t *testing.T) {
+	var stdout syncBuffer
+	p := &process.Process{Spec: process.Spec{
+		Cmd:           "/bin/sh",
+		Args:          []string{"-c", "echo $PROCESS_RUN_ID; echo 0123456789; sleep 0.1; echo abcdef >&2; exit 1"},
+		Stdout:        &stdout,
+		CaptureOutput: 14,
+		StartTimeout:  1000,
+	}}
+	res := <-p.Run(context.TODO())
+	if out := string(res.Output); out != "456789\nabcdef\n" {
+		t.Errorf("invalid output: %q", out)
+	}
+	if out := stdout.String(); out != res.RunID+"\n0123456789\n" {
+		t.Errorf("invalid stdout: %q", out)
+	}
+}
+
+func TestStreamPolicy(t *testing.T) {
+	var stdout, stderr syncBuffer
+	p := &process.Process{Spec: process.Spec{
+		Cmd:           "/bin/sh",
+		Args:          []string{"-c", "echo out; echo ready; echo err >&2; sleep 0.1; yes >&2"},
+		Stdout:        &stdout,
+		Stderr:        &stderr,
+		CaptureOutput: 100,
+		StartTimeout:  3000,
+		StopTimeout:   1000,
+		ReadyPattern:  "ready",
+		StderrLimit:   1000,
+		StdoutPolicy:  &process.StreamPolicy{Discard: true},
+		StderrPolicy:  &process.StreamPolicy{NoCapture: true, LimitAction: process.LimitKill},
+		LogLevel:      process.LogNone,
+	}}
+	res := <-p.Run(context.TODO())
+	if !errors.Is(res.Err, process.ErrOutputLimit) || res.Attempts != 1 {
+		t.Errorf("invalid result: %+v", res)
+	}
+	if stdout.String() != "" || len(res.Output) != 0 {
+		t.Errorf("discarded stdout written: %q %q", stdout.String(), res.Output)
+	}
+	if !strings.HasPrefix(stderr.String(), "err\n") {
+		t.Errorf("invalid stderr: %q", stderr.String())
+	}
+}
+
+func TestLineWriterConcurrent(t *testing.T) {
+	var buf syncBuffer
+	w := process.NewConsole(&buf).Writer("app")
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			line := fmt.Sprintf("writer %d\n", i)
+			for j := 0; j < 100; j++ {
+				fmt.Fprint(w, line)
+			}
+		}(i)
+	}
+	wg.Wait()
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 800 {
+		t.Fatalf("invalid number of lines: %d", len(lines))
+	}
+	for _, line := range lines {
+		if !regexp.MustCompile(`^app \| writer \d$`).MatchString(line) {
+			t.Fatalf("corrupted line: %q", line)
+		}
+	}
+}
+
+func TestFlushPartialLine(t *testing.T) {
+	var console, formatted, buffered syncBuffer
+	c := process.NewConsole(&console)
+	for _, spec := range []process.Spec{
+		{Stdout: c.Writer("app")},
+		{Stdout: &formatted, LineFormat: &process.LineFormat{Template: "{{.Stream}}: {{.Line}}"}},
+		{Stdout: c.Writer("async"), OutputBuffer: 64},
+		{Stdout: &buffered, Triggers: []process.Trigger{{Pattern: "^foo$", Action: process.ActionUnhealthy}}},
+	} {
+		spec.Cmd, spec.Args = "/bin/sh", []string{"-c", "printf foo"}
+		p := &process.Process{Spec: spec}
+		if res := <-p.Run(context.TODO()); res.Err != nil {
+			t.Fatal(res.Err)
+		}
+		if spec.Triggers != nil && p.Status().Unhealthy != "foo" {
+			t.Errorf("trigger did not match the last line: %+v", p.Status())
+		}
+	}
+	if out := formatted.String(); out != "stdout: foo\n" {
+		t.Errorf("invalid formatted output: %q", out)
+	}
+	deadline := time.Now().Add(time.Second)
+	for console.String() != "app   | foo\nasync | foo\n" && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if out := console.String(); out != "app   | foo\nasync | foo\n" {
+		t.Errorf("invalid console output: %q", out)
+	}
+}
@@ -0,0 +1,143 @@
+package process
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// Output overflow policies
+const (
+	OverflowBlock = "block" // Wait until the writer catches up, the child blocks on write
+	OverflowDrop  = "drop"  // Discard output that doesn't fit into the buffer
+	OverflowKill  = "kill"  // Discard the output and stop the process as failed
+)
+
+// asyncWriter passes output to a slow writer from its own goroutine through
+// a buffer of limited size, so that the child is not blocked by the writer
+type asyncWriter struct {
+	w        io.Writer
+	size     int
+	overflow string
+	dropped  *int64
+	onKill   func()
+
+	mu        sync.Mutex
+	cond      sync.Cond
+	buf       []byte
+	rotations []int // Offsets in buf where w is rotated
+	closed    bool
+	killed    bool
+}
+
+func newAsyncWriter(w io.Writer, size int, overflow string, dropped *int64, onKill func()) *asyncWriter {
+	aw := &asyncWriter{w: w, size: size, overflow: overflow, dropped: dropped, onKill: onKill}
+	aw.cond.L = &aw.mu
+	go aw.run()
+	return aw
+}
+
+func (aw *asyncWriter) Write(b []byte) (n int, err error) {
+	n = len(b)
+	aw.mu.Lock()
+	defer aw.mu.Unlock()
+	if aw.overflow == OverflowDrop || aw.overflow == OverflowKill {
+		if free := aw.size - len(aw.buf); len(b) > free {
+			atomic.AddInt64(aw.dropped, int64(len(b)-free))
+			b = b[:free]
+			if aw.onKill != nil && !aw.killed {
+				aw.killed = true
+				aw.onKill()
+			}
+		}
+	} else {
+		// Writes larger than the buffer are accepted once it is empty
+		for len(aw.buf) > 0 && len(aw.buf)+len(b) > aw.size && !aw.closed {
+			aw.cond.Wait()
+		}
+	}
+	if len(b) > 0 {
+		aw.buf = append(aw.buf, b...)
+		aw.cond.Broadcast()
+	}
+	return
+}
+
+// Rotate queues rotation of the writer implementing Rotator after the output
+// buffered so far. Errors of the rotation are not reported.
+func (aw *asyncWriter) Rotate() error {
+	aw.mu.Lock()
+	defer aw.mu.Unlock()
+	aw.rotations = append(aw.rotations, len(aw.buf))
+	aw.cond.Broadcast()
+	return nil
+}
+
+// Close lets the goroutine exit after the buffered output is written
+func (aw *asyncWriter) Close() error {
+	aw.mu.Lock()
+	defer aw.mu.Unlock()
+	aw.closed = true
+	aw.cond.Broadcast()
+	return nil
+}
+
+func (aw *asyncWriter) run() {
+	var (
+		data      []byte
+		rotations []int
+	)
+	aw.mu.Lock()
+	defer aw.mu.Unlock()
+	for {
+		for len(aw.buf) == 0 && len(aw.rotations) == 0 && !aw.closed {
+			aw.cond.Wait()
+		}
+		if len(aw.buf) == 0 && len(aw.rotations) == 0 {
+			if f, ok := aw.w.(flusher); ok {
+				aw.mu.Unlock()
+				f.Flush()
+				aw.mu.Lock()
+			}
+			return
+		}
+		data, aw.buf = aw.buf, data[:0]
+		rotations, aw.rotations = aw.rotations, rotations[:0]
+		aw.cond.Broadcast()
+		aw.mu.Unlock()
+		start := 0
+		for _, at := range rotations {
+			if at > start {
+				aw.w.Write(data[start:at])
+				start = at
+			}
+			aw.w.(Rotator).Rotate()
+		}
+		if start < len(data) {
+			aw.w.Write(data[start:])
+		}
+		aw.mu.Lock()
+	}
+}
+
+// asyncOutput wraps the user writer of the stream into asyncWriter if
+// OutputBuffer is set
+func (p *Process) asyncOutput(stream Stream, w io.Writer) io.Writer {
+	if w == nil || p.OutputBuffer <= 0 {
+		return w
+	}
+	var onKill func()
+	if p.OutputOverflow == OverflowKill {
+		reqs, size := p.requests, p.OutputBuffer
+		onKill = func() {
+			reqs.send(request{
+				err: ErrOutputOverflow,
+				msg: fmt.Sprintf("%s writer is %d bytes behind, stopping", stream, size),
+			})
+		}
+	}
+	aw := newAsyncWriter(w, p.OutputBuffer, p.OutputOverflow, &p.droppedOutput, onKill)
+	p.asyncs = append(p.asyncs, aw)
+	return aw
+}
@@ -0,0 +1,53 @@
+package process_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/andviro/process"
+)
+
+type slowWriter struct {
+	syncBuffer
+}
+
+func (w *slowWriter) Write(b []byte) (int, error) {
+	time.Sleep(20 * time.Millisecond)
+	return w.syncBuffer.Write(b)
+}
+
+func TestOutputOverflow(t *testing.T) {
+	for _, tc := range []struct {
+		overflow string
+		err      error
+	}{
+		{process.OverflowDrop, nil},
+		{process.OverflowKill, process.ErrOutputOverflow},
+	} {
+		p := &process.Process{Spec: process.Spec{
+			Cmd:            "/bin/sh",
+			Args:           []string{"-c", "for i in $(seq 1 500); do echo line $i; done; sleep 1"},
+			StartTimeout:   10,
+			StopTimeout:    1000,
+			Stdout:         new(slowWriter),
+			OutputBuffer:   64,
+			OutputOverflow: tc.overflow,
+		}}
+		start := time.Now()
+		res := <-p.Run(context.TODO())
+		if !errors.Is(res.Err, tc.err) {
+			t.Errorf("%s: invalid error: %v", tc.overflow, res.Err)
+		}
+		if elapsed := time.Since(start); elapsed > 3*time.Second {
+			t.Errorf("%s: child blocked by the writer for %v", tc.overflow, elapsed)
+		}
+		if st := p.Status(); st.DroppedOutput == 0 {
+			t.Errorf("%s: nothing dropped", tc.overflow)
+		}
+	}
+	if err := (process.Spec{Cmd: "/bin/true", OutputOverflow: "wait"}).Validate(); err == nil {
+		t.Error("invalid overflow accepted")
+	}
+}
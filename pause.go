@@ -0,0 +1,23 @@
+package process
+
+import (
+	"sync/atomic"
+)
+
+// Pause suspends the running child without changing its state
+func (p *Process) Pause() error {
+	pid := atomic.LoadInt32(&p.pid)
+	if pid == 0 {
+		return ErrNotRunning
+	}
+	return suspend(int(pid))
+}
+
+// Resume continues the child suspended by Pause
+func (p *Process) Resume() error {
+	pid := atomic.LoadInt32(&p.pid)
+	if pid == 0 {
+		return ErrNotRunning
+	}
+	return resume(int(pid))
+}
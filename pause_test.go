@@ -0,0 +1,45 @@
+package process_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/andviro/process"
+)
+
+func TestPause(t *testing.T) {
+	if err := new(process.Process).Pause(); !errors.Is(err, process.ErrNotRunning) {
+		t.Errorf("%+v", err)
+	}
+	var stdout syncBuffer
+	p := &process.Process{Spec: process.Spec{
+		Cmd:          "/bin/sh",
+		Args:         []string{"-c", "while true; do echo tick; sleep 0.01; done"},
+		Stdout:       &stdout,
+		StartTimeout: 50,
+		StopTimeout:  1000,
+	}}
+	ctx, cancel := context.WithCancel(context.TODO())
+	res := p.Run(ctx)
+	time.Sleep(100 * time.Millisecond)
+	if err := p.Pause(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	paused := stdout.String()
+	time.Sleep(100 * time.Millisecond)
+	if stdout.String() != paused {
+		t.Error("output continues while paused")
+	}
+	if err := p.Resume(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if stdout.String() == paused {
+		t.Error("output does not continue after resume")
+	}
+	cancel()
+	<-res
+}
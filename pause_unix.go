@@ -0,0 +1,15 @@
+//go:build !windows
+
+package process
+
+import (
+	"syscall"
+)
+
+func suspend(pid int) error {
+	return syscall.Kill(pid, syscall.SIGSTOP)
+}
+
+func resume(pid int) error {
+	return syscall.Kill(pid, syscall.SIGCONT)
+}
@@ -0,0 +1,34 @@
+package process
+
+import (
+	"fmt"
+	"syscall"
+)
+
+const processSuspendResume = 0x0800
+
+var (
+	ntdll                = syscall.NewLazyDLL("ntdll.dll")
+	procNtSuspendProcess = ntdll.NewProc("NtSuspendProcess")
+	procNtResumeProcess  = ntdll.NewProc("NtResumeProcess")
+)
+
+func suspend(pid int) error {
+	return callProcess(procNtSuspendProcess, pid)
+}
+
+func resume(pid int) error {
+	return callProcess(procNtResumeProcess, pid)
+}
+
+func callProcess(proc *syscall.LazyProc, pid int) error {
+	h, err := syscall.OpenProcess(processSuspendResume, false, uint32(pid))
+	if err != nil {
+		return err
+	}
+	defer syscall.CloseHandle(h)
+	if status, _, _ := proc.Call(uintptr(h)); status != 0 {
+		return fmt.Errorf("NTSTATUS 0x%08x", status)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Pipeline runs processes with the stdout of every stage connected to the
+// stdin of the next one, like a shell pipe under supervision. Stages are not
+// restarted on their own: as with pipefail, a failure of any stage stops the
+// others and the whole pipeline is restarted according to RestartPolicy.
+type Pipeline struct {
+	Stages         []Spec `json:"stages"`         // Stage configurations, restart settings of the stages are ignored
+	RestartPolicy  string `json:"restartPolicy"`  // One of: "always", "on-failure", ""
+	MaxRestarts    int    `json:"maxRestarts"`    // Maximum number of pipeline restarts, -1 for unlimited
+	RestartTimeout int    `json:"restartTimeout"` // Delay before restart in milliseconds
+}
+
+// PipelineResult describes the outcome of a completed pipeline Run
+type PipelineResult struct {
+	Stages   []RunResult `json:"stages"`   // Results of the stages in the last run
+	Restarts int         `json:"restarts"` // Number of pipeline restarts
+	Err      error       `json:"err"`      // Error of the first failed stage in the last run
+}
+
+// Validate checks the pipeline and every stage for mistakes
+func (pl Pipeline) Validate() error {
+	var errs []error
+	if len(pl.Stages) == 0 {
+		errs = append(errs, errors.New("stages: must not be empty"))
+	}
+	for i, s := range pl.Stages {
+		if err := s.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("stages[%d]: %w", i, err))
+		}
+	}
+	switch pl.RestartPolicy {
+	case "", "always", "on-failure":
+	default:
+		errs = append(errs, fmt.Errorf("restartPolicy: unknown policy %q", pl.RestartPolicy))
+	}
+	if pl.MaxRestarts < -1 {
+		errs = append(errs, errors.New("maxRestarts: must be -1 or greater"))
+	}
+	if pl.RestartTimeout < 0 {
+		errs = append(errs, errors.New("restartTimeout: must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+// Run starts the stages and returns a channel receiving the result once the
+// pipeline finishes and is not restarted, or the context is cancelled
+func (pl Pipeline) Run(ctx context.Context) <-chan PipelineResult {
+	res := make(chan PipelineResult, 1)
+	go func() {
+		defer close(res)
+		var r PipelineResult
+		for {
+			r.Stages, r.Err = pl.runOnce(ctx)
+			restart := pl.RestartPolicy == "always" || (pl.RestartPolicy == "on-failure" && r.Err != nil)
+			if !restart || ctx.Err() != nil || (pl.MaxRestarts != -1 && r.Restarts >= pl.MaxRestarts) {
+				break
+			}
+			t := time.NewTimer(time.Duration(pl.RestartTimeout) * time.Millisecond)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				res <- r
+				return
+			case <-t.C:
+			}
+			r.Restarts++
+		}
+		res <- r
+	}()
+	return res
+}
+
+// runOnce runs every stage once. A pipe is closed when the stage on either
+// end finishes, so that the next stage receives EOF and the previous one
+// gets EPIPE.
+func (pl Pipeline) runOnce(ctx context.Context) ([]RunResult, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	n := len(pl.Stages)
+	procs := make([]*Process, n)
+	files := make([][]*os.File, n) // Pipe ends of every stage
+	closeFiles := func(i int) {
+		for _, f := range files[i] {
+			f.Close()
+		}
+		files[i] = nil
+	}
+	var stdin *os.File
+	for i, s := range pl.Stages {
+		s = s.Clone()
+		s.RestartPolicy, s.MaxRestarts = "", 0
+		p := &Process{Spec: s}
+		if stdin != nil {
+			p.stdin = stdin
+			files[i] = append(files[i], stdin)
+		}
+		if i < n-1 {
+			r, w, err := os.Pipe()
+			if err != nil {
+				for j := 0; j <= i; j++ {
+					closeFiles(j)
+				}
+				return nil, err
+			}
+			p.Stdout = tee(w, s.Stdout)
+			files[i] = append(files[i], w)
+			stdin = r
+		}
+		procs[i] = p
+	}
+
+	type stageResult struct {
+		i int
+		r RunResult
+	}
+	finished := make(chan stageResult, n)
+	for i, p := range procs {
+		i, results := i, p.Run(ctx)
+		go func() { finished <- stageResult{i, <-results} }()
+	}
+	res := make([]RunResult, n)
+	var err error
+	for range procs {
+		sr := <-finished
+		res[sr.i] = sr.r
+		closeFiles(sr.i)
+		if sr.r.Err != nil && err == nil {
+			err = fmt.Errorf("stage %d: %w", sr.i, sr.r.Err)
+			cancel()
+		}
+	}
+	return res, err
+}
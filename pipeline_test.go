@@ -0,0 +1,67 @@
+package process_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/andviro/process"
+)
+
+func TestPipeline(t *testing.T) {
+	var out syncBuffer
+	pl := process.Pipeline{Stages: []process.Spec{
+		{Cmd: "/bin/sh", Args: []string{"-c", "printf 'b\\na\\nc\\n'"}, StartTimeout: 1000},
+		{Cmd: "/usr/bin/sort", StartTimeout: 1000},
+		{Cmd: "/usr/bin/tr", Args: []string{"a-z", "A-Z"}, StartTimeout: 1000, Stdout: &out},
+	}}
+	if err := pl.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	res := <-pl.Run(context.TODO())
+	if res.Err != nil || len(res.Stages) != 3 {
+		t.Fatalf("invalid result: %+v", res)
+	}
+	if s := out.String(); s != "A\nB\nC\n" {
+		t.Errorf("invalid output: %q", s)
+	}
+}
+
+func TestPipelineRestart(t *testing.T) {
+	pl := process.Pipeline{
+		Stages: []process.Spec{
+			{Cmd: "/bin/sleep", Args: []string{"5"}, StartTimeout: 10, StopTimeout: 1000},
+			{Cmd: "/bin/sh", Args: []string{"-c", "sleep 0.05; exit 3"}, StartTimeout: 10},
+		},
+		RestartPolicy:  "on-failure",
+		MaxRestarts:    2,
+		RestartTimeout: 10,
+	}
+	start := time.Now()
+	res := <-pl.Run(context.TODO())
+	if res.Err == nil || res.Restarts != 2 || res.Stages[1].ExitCode != 3 {
+		t.Errorf("invalid result: %+v", res)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("failed stage did not stop the pipeline: %v", elapsed)
+	}
+	if err := (process.Pipeline{RestartPolicy: "never"}).Validate(); err == nil {
+		t.Error("invalid pipeline accepted")
+	}
+}
+
+func TestPipelineEarlyReader(t *testing.T) {
+	var out syncBuffer
+	pl := process.Pipeline{Stages: []process.Spec{
+		{Cmd: "/usr/bin/yes", StartTimeout: 10, StopTimeout: 1000},
+		{Cmd: "/usr/bin/head", Args: []string{"-n", "1"}, StartTimeout: 10, Stdout: &out},
+	}}
+	select {
+	case <-pl.Run(context.TODO()):
+	case <-time.After(5 * time.Second):
+		t.Fatal("producer was not stopped")
+	}
+	if s := out.String(); s != "y\n" {
+		t.Errorf("invalid output: %q", s)
+	}
+}
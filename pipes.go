@@ -0,0 +1,86 @@
+package process
+
+import (
+	"io"
+)
+
+// StdoutPipe returns a reader receiving the child stdout of every start
+// attempt until the current (or the next, if the process is idle) run
+// finishes. The pipe is attached on the next start; the reader must be
+// drained, otherwise the child blocks on write.
+func (p *Process) StdoutPipe() io.ReadCloser {
+	return p.pipe(StreamStdout)
+}
+
+// StderrPipe is like StdoutPipe for the child stderr
+func (p *Process) StderrPipe() io.ReadCloser {
+	return p.pipe(StreamStderr)
+}
+
+func (p *Process) pipe(stream Stream) io.ReadCloser {
+	r, w := io.Pipe()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.pipes == nil {
+		p.pipes = make(map[Stream][]*io.PipeWriter)
+	}
+	p.pipes[stream] = append(p.pipes[stream], w)
+	return r
+}
+
+// pipesWriter copies the child output to the writer and all attached pipes
+type pipesWriter struct {
+	p      *Process
+	stream Stream
+	w      io.Writer
+}
+
+func (pw *pipesWriter) Write(b []byte) (n int, err error) {
+	n = len(b)
+	if pw.w != nil {
+		if n, err = pw.w.Write(b); err != nil {
+			return
+		}
+	}
+	pw.p.mu.RLock()
+	pipes := pw.p.pipes[pw.stream]
+	pw.p.mu.RUnlock()
+	for _, pipe := range pipes {
+		if _, err := pipe.Write(b); err != nil {
+			pw.p.detachPipe(pw.stream, pipe)
+		}
+	}
+	return
+}
+
+func (p *Process) pipesWriter(stream Stream, w io.Writer) io.Writer {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if len(p.pipes[stream]) == 0 {
+		return w
+	}
+	return &pipesWriter{p: p, stream: stream, w: w}
+}
+
+func (p *Process) detachPipe(stream Stream, pipe *io.PipeWriter) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pipes := p.pipes[stream]
+	for i := range pipes {
+		if pipes[i] == pipe {
+			p.pipes[stream] = append(pipes[:i:i], pipes[i+1:]...)
+			break
+		}
+	}
+}
+
+func (p *Process) closePipes() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, pipes := range p.pipes {
+		for _, pipe := range pipes {
+			pipe.Close()
+		}
+	}
+	p.pipes = nil
+}
@@ -0,0 +1,47 @@
+package process_test
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/andviro/process"
+)
+
+func TestPipes(t *testing.T) {
+	var stdout syncBuffer
+	p := &process.Process{Spec: process.Spec{
+		Cmd:           "/bin/sh",
+		Args:          []string{"-c", "echo out; echo err >&2; sleep 0.1"},
+		Stdout:        &stdout,
+		StartTimeout:  50,
+		RestartPolicy: "always",
+		MaxRestarts:   1,
+	}}
+	outPipe, errPipe := p.StdoutPipe(), p.StderrPipe()
+	closed := p.StdoutPipe()
+	closed.Close()
+
+	outc, errc := make(chan string), make(chan string)
+	for pipe, c := range map[io.Reader]chan string{outPipe: outc, errPipe: errc} {
+		go func(r io.Reader, c chan string) {
+			data, _ := io.ReadAll(r)
+			c <- string(data)
+		}(pipe, c)
+	}
+	select {
+	case <-p.Run(context.TODO()):
+	case <-time.After(3 * time.Second):
+		t.Fatal("process not stopped")
+	}
+	if out := <-outc; out != "out\nout\n" {
+		t.Errorf("invalid stdout: %q", out)
+	}
+	if out := <-errc; out != "err\nerr\n" {
+		t.Errorf("invalid stderr: %q", out)
+	}
+	if out := stdout.String(); out != "out\nout\n" {
+		t.Errorf("invalid output: %q", out)
+	}
+}
@@ -0,0 +1,80 @@
+package process
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// PreflightError lists problems found by pre-flight checks
+type PreflightError struct {
+	Errors []error
+}
+
+func (e *PreflightError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return "pre-flight checks failed: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the list of found problems
+func (e *PreflightError) Unwrap() []error {
+	return e.Errors
+}
+
+func (s Spec) preflight() error {
+	var errs []error
+	if s.Preflight {
+		path, err := s.cmdPath(s.Cmd)
+		if err == nil {
+			if strings.ContainsRune(path, os.PathSeparator) && !filepath.IsAbs(path) && s.Dir != "" {
+				path = filepath.Join(s.Dir, path)
+			}
+			_, err = exec.LookPath(path)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("executable: %w", err))
+		}
+		if s.Dir != "" {
+			if fi, err := os.Stat(s.Dir); err != nil {
+				errs = append(errs, fmt.Errorf("working directory: %w", err))
+			} else if !fi.IsDir() {
+				errs = append(errs, fmt.Errorf("working directory: %s is not a directory", s.Dir))
+			}
+		}
+	}
+	if len(s.RequireEnv) > 0 {
+		env := s.baseEnv()
+		for _, name := range s.RequireEnv {
+			if !hasEnv(env, name) {
+				errs = append(errs, fmt.Errorf("environment variable %s is not set", name))
+			}
+		}
+	}
+	for _, addr := range s.RequirePorts {
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("port %s: %w", addr, err))
+			continue
+		}
+		l.Close()
+	}
+	if errs != nil {
+		return &PreflightError{Errors: errs}
+	}
+	return nil
+}
+
+func hasEnv(env []string, name string) bool {
+	for _, kv := range env {
+		if strings.HasPrefix(kv, name+"=") {
+			return true
+		}
+	}
+	return false
+}
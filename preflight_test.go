@@ -0,0 +1,54 @@
+package process_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/andviro/process"
+)
+
+func TestPreflight(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	defer l.Close()
+
+	p := &process.Process{Spec: process.Spec{
+		Cmd:              "/nonexistent/binary",
+		Dir:              "/nonexistent",
+		Env:              []string{"A=1"},
+		Preflight:        true,
+		RequireEnv:       []string{"A", "B"},
+		RequirePorts:     []string{l.Addr().String()},
+		RestartPolicy:    "always",
+		MaxStartAttempts: 3,
+	}}
+	res := <-p.Run(context.TODO())
+	if res.State != process.StatePreflightFailed {
+		t.Errorf("invalid final state: %s", res.State)
+	}
+	if res.Attempts != 0 {
+		t.Errorf("start attempts burned: %d", res.Attempts)
+	}
+	var perr *process.PreflightError
+	if !errors.As(res.Err, &perr) {
+		t.Fatalf("%#v", res.Err)
+	}
+	if len(perr.Errors) != 4 {
+		t.Errorf("invalid errors: %v", perr)
+	}
+}
+
+func TestPreflightPassed(t *testing.T) {
+	p := &process.Process{Spec: process.Spec{
+		Cmd:        "true",
+		Preflight:  true,
+		RequireEnv: []string{"PATH"},
+	}}
+	if res := <-p.Run(context.TODO()); res.Err != nil || res.State != process.StateStopped {
+		t.Errorf("invalid result: %+v", res)
+	}
+}
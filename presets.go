@@ -0,0 +1,67 @@
+package process
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Presets is a registry of named process templates. The zero value is ready
+// to use.
+type Presets struct {
+	mu    sync.RWMutex
+	specs map[string]Spec
+}
+
+// Add registers a template under the name, replacing the previous one
+func (r *Presets) Add(name string, s Spec) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.specs == nil {
+		r.specs = make(map[string]Spec)
+	}
+	r.specs[name] = s.Clone()
+}
+
+// Remove unregisters the template
+func (r *Presets) Remove(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.specs, name)
+}
+
+// Names returns sorted list of registered templates
+func (r *Presets) Names() (res []string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for name := range r.specs {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return
+}
+
+// Spec returns a copy of the named template with overrides applied in order
+func (r *Presets) Spec(name string, overrides ...func(*Spec)) (res Spec, err error) {
+	r.mu.RLock()
+	tpl, ok := r.specs[name]
+	r.mu.RUnlock()
+	if !ok {
+		return res, fmt.Errorf("%w: %q", ErrUnknownPreset, name)
+	}
+	res = tpl.Clone()
+	for _, override := range overrides {
+		override(&res)
+	}
+	return
+}
+
+// New instantiates a process from the named template with overrides applied
+// in order
+func (r *Presets) New(name string, overrides ...func(*Spec)) (*Process, error) {
+	s, err := r.Spec(name, overrides...)
+	if err != nil {
+		return nil, err
+	}
+	return &Process{Spec: s}, nil
+}
@@ -0,0 +1,36 @@
+package process_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/andviro/process"
+)
+
+func TestPresets(t *testing.T) {
+	var r process.Presets
+	r.Add("worker", process.NewSpec("/bin/sh", "-c", "exit $CODE"))
+	p, err := r.New("worker", func(s *process.Spec) {
+		s.Env = append(s.Env, "CODE=2")
+	})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if res := <-p.Run(context.TODO()); res.ExitCode != 2 {
+		t.Errorf("invalid exit code: %d", res.ExitCode)
+	}
+	s, err := r.Spec("worker")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if s.Env != nil {
+		t.Errorf("template modified: %v", s.Env)
+	}
+	if _, err := r.New("missing"); !errors.Is(err, process.ErrUnknownPreset) {
+		t.Errorf("%#v", err)
+	}
+	if names := r.Names(); len(names) != 1 || names[0] != "worker" {
+		t.Errorf("invalid names: %v", names)
+	}
+}
@@ -4,10 +4,14 @@ import (
 	"gopkg.in/andviro/go-state.v2"
 
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"sync"
+	"sync/atomic"
+	"text/template"
 	"time"
 )
 
@@ -22,144 +26,662 @@ const (
 // Process presents basic execution unit
 type Process struct {
 	// Initial configuration
-	Cmd              string    `json:"cmd"`              // A path to executable to run
-	Args             []string  `json:"args"`             // Command-line argument list
-	Dir              string    `json:"dir"`              // Process working directory
-	Env              []string  `json:"env"`              // Inital environment
-	Stdout, Stderr   io.Writer `json:"-"`                // Standard IO pipes
-	StartTimeout     int       `json:"startTimeout"`     // Time to wait for process start in milliseconds
-	BackoffTimeout   int       `json:"backoffTimeout"`   // Delay before another start attempt
-	StopTimeout      int       `json:"stopTimeout"`      // Time to wait for process stop in milliseconds
-	KillTimeout      int       `json:"killTimeout"`      // Time to wait after sending the kill signal in milliseconds
-	MaxStartAttempts int       `json:"maxStartAttempts"` // Maximum number of start attempts (default to give up first time)
-	MaxRestarts      int       `json:"maxRestarts"`      // Maximum number of restarts (default to no restarts)
-	RestartTimeout   int       `json:"restartTimeout"`   // Delay before restart attempt
-	RestartPolicy    string    `json:"restartPolicy"`    // One of: "always", "on-error", ""
+	Spec
 
 	// Process run-time parameters
-	StartAttempt int    `json:"startAttempt"` // Current number of start attempts
+	StartAttempt int    `json:"startAttempt"` // Number of consecutive failed launches
 	RestartCount int    `json:"restartCount"` // Current number of runs
-	State        string `json:"state"`        // Current process state
+	State        State  `json:"state"`        // Current process state
 	LastError    error  `json:"lastError"`    // Last error encountered
+	RunID        string `json:"runId"`        // Unique identifier of the current start attempt
 
-	Stop   context.CancelFunc
-	cmd    *exec.Cmd
-	result chan error
-}
+	cancel     context.CancelFunc // Cancels the current run, guarded by mu
+	stopCalled int32
+	cmd        *exec.Cmd
+	result     chan error
+	starts     int
+	exitCode   int
+	pid        int32
+	started    time.Time
+	exitedAt   time.Time
+	subs       map[*subscriber]struct{}
+	pipes      map[Stream][]*io.PipeWriter
+	capture    *tailBuffer
+	stdin      io.Reader // Child stdin set by Pipeline, overrides StdinFile and StdinFunc
+	asyncs     []*asyncWriter
+	flushers   []flusher     // Writers holding incomplete lines, flushed in reverse order on exit
+	outs       []*outputPipe // Output pipes of the current child, guarded by mu
+	handoff    *handoff      // Child inherited from the previous supervisor image, guarded by mu
+	term       *terminal     // Pseudo-terminal of the current child with PTY, guarded by mu
+	// Bytes discarded by asyncs, accessed atomically
+	droppedOutput int64
 
-func (p *Process) logf(format string, args ...interface{}) (n int, err error) {
-	if p.Stderr == nil {
-		return
-	}
-	return fmt.Fprintf(p.Stderr, format, args...)
+	triggers  compiledTriggers
+	requests  requests
+	ready     chan struct{}
+	unhealthy string
+
+	drain   chan struct{} // Closed by Drain
+	drained bool
+	done    chan struct{} // Closed after the run is finished
+	err     error         // Error of the finished run
+
+	next  state.Func // State to enter after the child has been stopped
+	cause error      // Reason of the stop requested by the package itself
+
+	since      time.Time
+	stopSince  time.Time // Time when the stop signal was sent
+	timer      *time.Timer
+	durations  map[State]time.Duration
+	uptime     time.Duration
+	downtime   time.Duration
+	cleanExits int
+	crashExits int
+
+	mu     sync.RWMutex
+	status Status
+	active bool
 }
 
 // New creates process with reasonable defaults
 func New(cmd string, args ...string) (res *Process) {
-	res = new(Process)
-	res.StartTimeout = startTimeout
-	res.StopTimeout = stopTimeout
-	res.BackoffTimeout = backoffTimeout
-	res.RestartTimeout = restartTimeout
-	res.KillTimeout = killTimeout
-	res.MaxStartAttempts = 10
-	res.MaxRestarts = -1
+	return &Process{Spec: NewSpec(cmd, args...)}
+}
+
+// Clone creates a new process with a copy of the configuration. Run-time
+// parameters are not copied.
+func (p *Process) Clone() *Process {
+	return &Process{Spec: p.Spec.Clone()}
+}
+
+// Status returns a snapshot of process run-time parameters taken at the last
+// state change
+func (p *Process) Status() (res Status) {
+	p.mu.RLock()
+	res = p.status
+	res.Unhealthy = p.unhealthy
+	p.mu.RUnlock()
+	res.DroppedOutput = atomic.LoadInt64(&p.droppedOutput)
+	switch {
+	case res.State.up():
+		res.Uptime += res.Elapsed()
+	case !res.State.terminal() && res.State != StateIdle:
+		res.Downtime += res.Elapsed()
+	}
 	return
 }
 
-// Run starts process execution
-func (p *Process) Run(ctx context.Context) (res chan error) {
-	res = make(chan error, 1)
-	ctx, p.Stop = context.WithCancel(ctx)
+// Output returns the output tail of the current or last child kept with
+// CaptureOutput
+func (p *Process) Output() []byte {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.capture.Bytes()
+}
+
+// IsRunning reports whether the child is running, including draining
+func (p *Process) IsRunning() bool {
+	return p.state().up()
+}
+
+// IsTerminal reports whether the process has reached its final state
+func (p *Process) IsTerminal() bool {
+	return p.state().terminal()
+}
+
+// InState reports whether the process is in one of the states
+func (p *Process) InState(states ...State) bool {
+	cur := p.state()
+	for _, s := range states {
+		if s == cur {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Process) state() State {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.status.State
+}
+
+// Run starts process execution. The returned channel receives the outcome
+// once the process reaches its final state. Calling Run on a process that
+// has not finished yet results in ErrAlreadyRunning.
+func (p *Process) Run(ctx context.Context) (res chan RunResult) {
+	res = make(chan RunResult, 1)
+	startedAt := time.Now()
 
+	p.mu.Lock()
+	if p.active {
+		st := p.status
+		p.mu.Unlock()
+		res <- RunResult{State: st.State, ExitCode: -1, StartedAt: startedAt, StoppedAt: startedAt, Err: ErrAlreadyRunning}
+		close(res)
+		return
+	}
+	p.active = true
+	p.drain, p.drained = make(chan struct{}), false
+	p.done, p.err = make(chan struct{}), nil
+	p.mu.Unlock()
+	p.since, p.stopSince = time.Time{}, time.Time{}
+	p.exitCode = -1
+	p.started, p.exitedAt = time.Time{}, time.Time{}
+
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	atomic.StoreInt32(&p.stopCalled, 0)
+	p.mu.Lock()
+	p.cancel = cancel
+	p.mu.Unlock()
 	go func() {
 		defer close(res)
-		res <- state.Run(ctx, p.starting, func(ctx context.Context) error {
-			p.State = state.Name(ctx)
+		err := state.Run(ctx, p.starting, func(ctx context.Context) error {
+			p.State = states[state.Name(ctx)]
+			p.transition()
 			return nil
 		})
+		cancel()
+		p.stopTimer()
+		p.closeSubscribers()
+		p.closePipes()
+		result := p.runResult(startedAt, err)
+		result.Reason = p.stopReason(parent)
+		p.mu.Lock()
+		p.status.Reason = result.Reason
+		p.active = false
+		p.err = result.Err
+		close(p.done)
+		p.mu.Unlock()
+		res <- result
 	}()
 	return
 }
 
-func (p *Process) starting(c context.Context) (res state.Func) {
-	p.logf("%v starting %s", time.Now(), p.Cmd)
+// Stop initiates shutdown of the current run. It does nothing before Run and
+// after the run is finished.
+func (p *Process) Stop() {
+	p.mu.RLock()
+	cancel, active := p.cancel, p.active
+	p.mu.RUnlock()
+	if cancel == nil || !active {
+		return
+	}
+	atomic.StoreInt32(&p.stopCalled, 1)
+	cancel()
+}
+
+// Reset clears run-time parameters of a finished process so it can be run
+// again from scratch
+func (p *Process) Reset() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.active {
+		return ErrAlreadyRunning
+	}
+	p.StartAttempt = 0
+	p.RestartCount = 0
+	p.State = StateIdle
+	p.LastError = nil
+	p.RunID = ""
+	p.cmd = nil
+	p.capture = nil
+	p.next, p.cause = nil, nil
+	p.unhealthy = ""
+	p.starts = 0
+	p.since = time.Time{}
+	p.durations = nil
+	p.uptime, p.downtime = 0, 0
+	p.cleanExits, p.crashExits = 0, 0
+	p.status = Status{}
+	return nil
+}
 
-	p.cmd = exec.Command(p.Cmd, p.Args...)
-	p.cmd.Dir = p.Dir
-	p.cmd.Env = p.Env
-	p.cmd.Stdout = p.Stdout
-	p.cmd.Stderr = p.Stderr
+func (p *Process) transition() {
+	now := time.Now()
+	prev := p.status.State
+	var elapsed time.Duration
+	if !p.since.IsZero() {
+		elapsed = now.Sub(p.since)
+		durations := make(map[State]time.Duration, len(p.durations)+1)
+		for k, v := range p.durations {
+			durations[k] = v
+		}
+		durations[prev] = elapsed
+		p.durations = durations
+		switch {
+		case prev.up():
+			p.uptime += elapsed
+		case !prev.terminal():
+			p.downtime += elapsed
+		}
+	}
+	p.since = now
+	if p.Metrics != nil {
+		p.observePhase(prev, elapsed, now)
+	}
 
-	if p.LastError = p.cmd.Start(); p.LastError != nil {
-		p.logf("%v error starting %s: %v", time.Now(), p.Cmd, p.LastError)
+	st := Status{
+		State:        p.State,
+		StartAttempt: p.StartAttempt,
+		RestartCount: p.RestartCount,
+		LastError:    p.LastError,
+		RunID:        p.RunID,
+		Since:        now,
+		Durations:    p.durations,
+		StartedAt:    p.started,
+		StoppedAt:    p.exitedAt,
+		Uptime:       p.uptime,
+		Downtime:     p.downtime,
+		CleanExits:   p.cleanExits,
+		CrashExits:   p.crashExits,
+	}
+	switch p.State {
+	case StateRunning, StateDraining, StateStopping, StateKilling:
+		st.Pid = p.cmd.Process.Pid
+	}
+	p.mu.Lock()
+	p.status = st
+	p.mu.Unlock()
+
+	if p.OnStateChange != nil {
+		p.OnStateChange(prev, p.State, p.LastError, now)
+	}
+	e := Event{Name: p.procName(), Prev: prev, State: p.State, RunID: p.RunID, Time: now, Elapsed: elapsed, Err: p.LastError}
+	if p.Sink != nil {
+		p.Sink.Event(e)
+	}
+	p.publish(e)
+}
+
+// exited accounts child exit. Exits caused by stop request are considered clean.
+func (p *Process) exited(err error) {
+	p.exitCode = p.cmd.ProcessState.ExitCode()
+	p.exitedAt = time.Now()
+	atomic.StoreInt32(&p.pid, 0)
+	if err != nil {
+		if p.CrashDir != "" || p.OnCrash != nil {
+			p.collectCrash(err)
+		}
+		p.crashExits++
+	} else {
+		p.cleanExits++
+	}
+	if p.OnSurvivors != nil {
+		p.checkSurvivors()
+	}
+}
+
+func (p *Process) starting(c context.Context) (res state.Func) {
+	// A child handed over by the previous supervisor image is already
+	// started and ready
+	h := p.takeHandoff()
+	// Variables of the start attempt are not known before the launch, checks
+	// see the base environment only
+	pre := p.Spec.expanded(p.baseEnv())
+	if p.CreateDir && pre.Dir != "" && h == nil {
+		if err := os.MkdirAll(pre.Dir, p.dirMode()); err != nil {
+			p.LastError = &StartError{Cmd: p.Cmd, Err: err}
+			p.errorf("%v", p.LastError)
+			return p.failed
+		}
+	}
+	if h == nil {
+		if p.LastError = pre.preflight(); p.LastError != nil {
+			p.errorf("%v", p.LastError)
+			return p.preflightFailed
+		}
+	}
+	if p.triggers, p.LastError = compileTriggers(p.Triggers); p.LastError != nil {
+		p.errorf("%v", p.LastError)
 		return p.failed
 	}
+	readyPattern, err := compileReadyPattern(p.ReadyPattern)
+	if err != nil {
+		p.LastError = fmt.Errorf("ready pattern: %w", err)
+		p.errorf("%v", p.LastError)
+		return p.failed
+	}
+	var format *template.Template
+	if p.LineFormat != nil {
+		if format, err = p.LineFormat.compile(); err != nil {
+			p.LastError = fmt.Errorf("line format: %w", err)
+			p.errorf("%v", p.LastError)
+			return p.failed
+		}
+	}
+	if p.StartLimiter != nil && h == nil {
+		if err := p.StartLimiter.Wait(c); err != nil {
+			return p.stopped
+		}
+	}
+	if c.Err() != nil && h == nil {
+		return p.stopped
+	}
+	p.RunID = newRunID()
+	if h != nil {
+		p.RunID = h.runID
+	}
+	p.starts++
+	p.requests = make(requests, 1)
+	p.mu.Lock()
+	p.unhealthy = ""
+	p.mu.Unlock()
+
+	env := p.environ(c)
+	launch := p.Spec
+	if p.ArgsFunc != nil {
+		launch.Args = p.ArgsFunc(p.starts)
+	}
+	launch = launch.expanded(env)
+	if h != nil {
+		p.logf("resuming pid %d", h.proc.Pid)
+		p.cmd = &exec.Cmd{Path: launch.Cmd, Args: append([]string{launch.Cmd}, launch.Args...), Dir: launch.Dir, Env: env, Process: h.proc}
+	} else if err := p.command(launch, env); err != nil {
+		p.LastError = &StartError{Cmd: p.Cmd, Err: err}
+		p.errorf("%v", p.LastError)
+		return p.failed
+	}
+	var capture *tailBuffer
+	if p.CaptureOutput > 0 {
+		capture = newTailBuffer(p.CaptureOutput)
+	}
+	p.mu.Lock()
+	p.capture = capture
+	p.mu.Unlock()
+	mode := p.readyMode()
+	if h != nil {
+		mode = ReadyAfterTimeout
+	}
+	if err := p.validateReady(); err != nil {
+		p.LastError = fmt.Errorf("ready mode: %w", err)
+		p.errorf("%v", p.LastError)
+		return p.failed
+	}
+	p.ready = nil
+	if mode != ReadyAfterTimeout {
+		p.ready = make(chan struct{})
+	}
+	if mode != ReadyOnPattern {
+		readyPattern = nil
+	}
+	var notify *notifySocket
+	if mode == ReadyOnNotify {
+		if notify, err = listenNotify(p.RunID); err != nil {
+			p.LastError = &StartError{Cmd: p.Cmd, Err: err}
+			p.errorf("%v", p.LastError)
+			return p.failed
+		}
+		p.cmd.Env = append(p.cmd.Env, NotifySocketEnv+"="+notify.path)
+	}
+	atomic.StoreInt32(&p.pid, 0)
+	var stdin io.Reader
+	if h == nil {
+		if stdin, err = p.openStdin(launch.Dir); err != nil {
+			if notify != nil {
+				notify.conn.Close()
+				os.Remove(notify.path)
+			}
+			p.LastError = &StartError{Cmd: p.Cmd, Err: err}
+			p.errorf("%v", p.LastError)
+			return p.failed
+		}
+	}
+	p.cmd.Stdin = stdin
+	stdout, stderr := p.outputs(readyPattern, format)
+	p.exitCode = -1
+
+	var outs []*outputPipe
+	if h != nil {
+		outs = h.attach(stdout, stderr)
+		p.started = h.started
+	} else {
+		if p.PTY {
+			outs, err = p.startPTY(stdin, stdout)
+		} else if outs, err = pipeOutputs(p.cmd, stdout, stderr); err == nil {
+			err = p.cmd.Start()
+			closeWriters(p.cmd)
+		}
+		if err != nil {
+			closeOutputs(outs)
+			for _, aw := range p.asyncs {
+				aw.Close()
+			}
+			p.closeStdin(stdin)
+			if notify != nil {
+				notify.conn.Close()
+				os.Remove(notify.path)
+			}
+			p.LastError = &StartError{Cmd: p.Cmd, Err: err}
+			p.errorf("%v", p.LastError)
+			return p.failed
+		}
+		p.started = time.Now()
+	}
+	var term *terminal
+	if p.PTY && len(outs) != 0 {
+		var rec *castWriter
+		if p.PTYRecordDir != "" {
+			var err error
+			if rec, err = openCast(p.PTYRecordDir, p.RunID, p.Cmd, defaultRows, defaultCols, p.dirMode()); err != nil {
+				p.warnf("pty recording: %v", err)
+			}
+		}
+		term = newTerminal(outs[0], rec)
+	}
+	for _, o := range outs {
+		go o.copy()
+	}
+	p.mu.Lock()
+	p.outs, p.term = outs, term
+	p.mu.Unlock()
+	atomic.StoreInt32(&p.pid, int32(p.cmd.Process.Pid))
+	if p.CoreDump {
+		if err := allowCore(p.cmd.Process.Pid); err != nil {
+			p.warnf("core dump limit: %v", err)
+		}
+	}
 	p.result = make(chan error, 1)
+	waited := make(chan struct{})
+	asyncs, flushers := p.asyncs, p.flushers
 	go func() {
 		defer close(p.result)
-		p.result <- p.cmd.Wait()
+		err := p.cmd.Wait()
+		drainOutputs(outs, p.waitDelay())
+		if term != nil {
+			p.mu.Lock()
+			p.term = nil
+			p.mu.Unlock()
+			term.close()
+		}
+		for i := len(flushers) - 1; i >= 0; i-- {
+			flushers[i].Flush()
+		}
+		for _, aw := range asyncs {
+			aw.Close()
+		}
+		p.closeStdin(stdin)
+		close(waited)
+		p.result <- p.exitError(err)
 	}()
+	switch mode {
+	case ReadyOnProbe:
+		go p.probeReady(p.ready, waited)
+	case ReadyOnNotify:
+		go notify.wait(p.ready, waited)
+	}
+	if p.WatchChildren > 0 && p.OnFork != nil {
+		go p.watchChildren(p.cmd.Process.Pid, waited)
+	}
+	if p.CPUThreshold != nil {
+		go p.watchCPU(p.cmd.Process.Pid, p.RunID, p.requests, waited)
+	}
+	if len(p.WatchPaths) > 0 {
+		go p.watchPaths(p.requests, waited)
+	}
+	if p.FDThreshold != nil {
+		go p.watchFDs(p.cmd.Process.Pid, p.RunID, p.requests, waited)
+	}
+	if p.Profiler != nil {
+		go p.sampleProfiles(p.cmd.Process.Pid, p.RunID, waited)
+	}
+	if h != nil {
+		p.LastError = nil
+		p.StartAttempt = 0
+		return p.running
+	}
 
 	select {
 	case <-c.Done():
 		return p.stopping
 	case p.LastError = <-p.result:
-		p.logf("%v %s finished with error: %v", time.Now(), p.Cmd, p.LastError)
-		switch p.RestartPolicy {
-		case "on-failure":
-			if p.LastError == nil {
-				break
-			}
-			fallthrough
-		case "always":
+		p.logf("finished with error: %v", p.LastError)
+		p.exited(p.LastError)
+		// Failed launches are retried up to MaxStartAttempts, while a clean exit
+		// is a completed run counted against MaxRestarts
+		switch {
+		case !p.restartable(p.LastError):
+			return p.stopped
+		case p.LastError != nil:
 			return p.backoff
 		}
-		return p.stopped
-	case <-time.After(time.Duration(p.StartTimeout) * time.Millisecond):
-		p.LastError = nil
 		p.StartAttempt = 0
+		return p.restarting
+	case <-p.ready:
+		p.debugf("ready")
+	case <-p.after(p.StartTimeout):
+		if p.ready != nil {
+			p.errorf("%v", ErrNotReady)
+			p.next, p.cause = p.backoff, ErrNotReady
+			return p.stopping
+		}
 	}
+	p.LastError = nil
+	p.StartAttempt = 0
 	return p.running
 }
 
+// command prepares the child command of the start attempt
+func (p *Process) command(launch Spec, env []string) error {
+	cmd := launch.Cmd
+	args, err := launch.expandArgFiles(launch.Args)
+	if err != nil {
+		return err
+	}
+	p.logf("starting %s", p.displayCommand(cmd, args))
+	if cmd, err = launch.cmdPath(cmd); err != nil {
+		return err
+	}
+	if p.Elevate != "" {
+		if cmd, args, err = p.elevate(cmd, args); err != nil {
+			return err
+		}
+	}
+	p.cmd = exec.Command(cmd, args...)
+	p.cmd.Dir = launch.Dir
+	p.cmd.WaitDelay = p.waitDelay()
+	p.cmd.Env = env
+	setProcAttr(p.cmd, p.Detach, p.PTY)
+	return nil
+}
+
 func (p *Process) stopping(c context.Context) (res state.Func) {
-	if p.LastError = p.cmd.Process.Signal(os.Interrupt); p.LastError != nil {
+	if len(p.StopSequence) != 0 {
+		return p.escalate(c)
+	}
+	// The child may have exited just before the signal, its result is
+	// taken below
+	if err := interrupt(p.cmd.Process); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		p.LastError = err
 		return p.failed
 	}
 	select {
 	case p.LastError = <-p.result:
-		break
-	case <-time.After(time.Duration(p.StopTimeout) * time.Millisecond):
+		var exitErr *ExitError
+		if errors.As(p.LastError, &exitErr) {
+			// Exit status is the reaction to the stop signal sent above
+			p.LastError = nil
+		}
+		p.exited(nil)
+	case <-p.after(p.StopTimeout):
 		return p.killing
 	}
-	return p.stopped
+	return p.afterStop(c)
 }
 
 func (p *Process) killing(c context.Context) (res state.Func) {
-	if p.LastError = p.cmd.Process.Signal(os.Kill); p.LastError != nil {
+	if err := p.cmd.Process.Signal(os.Kill); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		p.LastError = err
 		return p.failed
 	}
 	select {
-	case p.LastError = <-p.result:
-		break
-	case <-time.After(time.Duration(p.KillTimeout) * time.Millisecond):
-		p.LastError = fmt.Errorf("failed to kill process")
+	case err := <-p.result:
+		p.LastError = wrap(ErrStopTimeout, err)
+		p.exited(nil)
+	case <-p.after(p.KillTimeout):
+		return p.killGroup(c)
+	}
+	return p.afterStop(c)
+}
+
+// killGroup is the last resort after the child survived the kill signal: the
+// whole process group is killed and the child is checked for liveness
+func (p *Process) killGroup(c context.Context) state.Func {
+	pid := p.cmd.Process.Pid
+	p.warnf("process survived kill signal")
+	if err := killGroup(pid); err != nil {
+		p.errorf("kill process group: %v", err)
+	}
+	// Signal delivery is asynchronous, give the group some time even if
+	// KillTimeout is zero
+	select {
+	case err := <-p.result:
+		p.LastError = wrap(ErrStopTimeout, err)
+		p.exited(nil)
+		return p.afterStop(c)
+	case <-p.after(int(p.waitDelay() / time.Millisecond)):
+	}
+	if processAlive(pid, "") {
+		p.LastError = ErrKillFailed
+		if p.OnAlert != nil {
+			p.OnAlert(Alert{Kind: AlertUnkillable, RunID: p.RunID, Pid: pid, Time: time.Now()})
+		}
 		return p.failed
 	}
+	// The child is gone, Wait returns after WaitDelay closes the pipes held
+	// by its descendants
+	p.LastError = wrap(ErrStopTimeout, <-p.result)
+	p.exited(nil)
+	return p.afterStop(c)
+}
+
+// afterStop selects the state to enter once the child has been stopped
+func (p *Process) afterStop(c context.Context) state.Func {
+	next, cause := p.next, p.cause
+	p.next, p.cause = nil, nil
+	if cause != nil {
+		p.LastError = cause
+	}
+	if next != nil && c.Err() == nil {
+		return next
+	}
 	return p.stopped
 }
 
 func (p *Process) backoff(c context.Context) (res state.Func) {
 	p.StartAttempt++
 	if p.MaxStartAttempts != -1 && p.StartAttempt > p.MaxStartAttempts {
-		p.logf("%v %s maximum start attempts reached", time.Now(), p.Cmd)
+		p.errorf("maximum start attempts reached")
+		p.LastError = wrap(ErrMaxStartAttempts, p.LastError)
 		return p.failed
 	}
 	select {
 	case <-c.Done():
-		return p.stopping
-	case <-time.After(time.Duration(p.BackoffTimeout) * time.Millisecond):
+		return p.stopped
+	case <-p.drain:
+		return p.stopped
+	case <-p.after(p.jittered(p.BackoffTimeout)):
 		return p.starting
 	}
 }
@@ -168,19 +690,26 @@ func (p *Process) failed(c context.Context) (res state.Func) {
 	return
 }
 
+func (p *Process) preflightFailed(c context.Context) (res state.Func) {
+	return
+}
+
 func (p *Process) restarting(c context.Context) (res state.Func) {
 	p.RestartCount++
 	if p.MaxRestarts != -1 && p.RestartCount > p.MaxRestarts {
-		p.logf("%v %s maximum restart count reached", time.Now(), p.Cmd)
+		p.errorf("maximum restart count reached")
 		if p.LastError != nil {
+			p.LastError = wrap(ErrMaxRestarts, p.LastError)
 			return p.failed
 		}
 		return p.stopped
 	}
 	select {
 	case <-c.Done():
-		return p.stopping
-	case <-time.After(time.Duration(p.RestartTimeout) * time.Millisecond):
+		return p.stopped
+	case <-p.drain:
+		return p.stopped
+	case <-p.after(p.jittered(p.RestartTimeout)):
 		return p.starting
 	}
 }
@@ -188,23 +717,42 @@ func (p *Process) restarting(c context.Context) (res state.Func) {
 func (p *Process) running(c context.Context) (res state.Func) {
 	select {
 	case <-c.Done():
-		p.logf("%v %s received cancel signal", time.Now(), p.Cmd)
+		p.debugf("received cancel signal")
+		return p.stopping
+	case <-p.drain:
+		p.logf("draining")
+		return p.draining
+	case r := <-p.requests:
+		p.logf("%s", r.msg)
+		p.cause = r.err
+		if (r.restart && !p.isDraining()) || p.restartable(r.err) {
+			p.next = p.restarting
+		}
 		return p.stopping
 	case p.LastError = <-p.result:
-		p.logf("%v %s finished with error: %v", time.Now(), p.Cmd, p.LastError)
-		switch p.RestartPolicy {
-		case "on-failure":
-			if p.LastError == nil {
-				break
-			}
-			fallthrough
-		case "always":
+		p.logf("finished with error: %v", p.LastError)
+		p.exited(p.LastError)
+		if p.restartable(p.LastError) {
 			return p.restarting
 		}
 		return p.stopped
 	}
 }
 
+// restartable reports whether the restart policy allows another run after exit with the error
+func (p *Process) restartable(err error) bool {
+	if p.isDraining() {
+		return false
+	}
+	switch p.RestartPolicy {
+	case "always":
+		return true
+	case "on-failure":
+		return err != nil
+	}
+	return false
+}
+
 func (p *Process) stopped(c context.Context) (res state.Func) {
 	return
 }
@@ -2,7 +2,9 @@ package process_test
 
 import (
 	"context"
-	"os/exec"
+	"errors"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -10,42 +12,66 @@ import (
 )
 
 func TestRun(t *testing.T) {
-	p := &process.Process{
+	p := &process.Process{Spec: process.Spec{
 		Cmd:  "/bin/sleep",
 		Args: []string{"1"},
+	}}
+	res := <-p.Run(context.TODO())
+	if res.Err != nil {
+		t.Errorf("%+v", res.Err)
 	}
-	if err := <-p.Run(context.TODO()); err != nil {
-		t.Fatalf("%+v", err)
+	if res.State != process.StateStopped {
+		t.Errorf("invalid final state: %s", res.State)
 	}
-	if p.LastError != nil {
-		t.Errorf("%+v", p.LastError)
+	if res.ExitCode != 0 {
+		t.Errorf("invalid exit code: %d", res.ExitCode)
 	}
-	if p.State != "stopped" {
-		t.Errorf("invalid final state: %s", p.State)
+	if res.Attempts != 1 {
+		t.Errorf("invalid number of attempts: %d", res.Attempts)
+	}
+	if !res.StoppedAt.After(res.StartedAt) {
+		t.Errorf("invalid timestamps: %v, %v", res.StartedAt, res.StoppedAt)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	p := &process.Process{Spec: process.Spec{
+		Cmd:  "/bin/sh",
+		Args: []string{"-c", "exit 3"},
+	}}
+	res := <-p.Run(context.TODO())
+	if res.ExitCode != 3 {
+		t.Errorf("invalid exit code: %d", res.ExitCode)
+	}
+	var exitErr *process.ExitError
+	if !errors.As(res.Err, &exitErr) || exitErr.ExitCode() != 3 {
+		t.Errorf("%#v", res.Err)
+	}
+	if _, ok := exitErr.Signaled(); ok {
+		t.Error("exit is reported as signaled")
 	}
 }
 
 func TestRestart(t *testing.T) {
-	p := &process.Process{
+	p := &process.Process{Spec: process.Spec{
 		Cmd:           "/bin/sleep",
 		Args:          []string{"1"},
 		RestartPolicy: "always",
 		MaxRestarts:   3,
 		StartTimeout:  100,
 		StopTimeout:   1000,
-	}
+	}}
 
-	res := p.Run(context.TODO())
 	select {
-	case err := <-res:
-		if err != nil {
-			t.Fatalf("%+v", err)
+	case res := <-p.Run(context.TODO()):
+		if res.Err != nil {
+			t.Errorf("%#v", res.Err)
 		}
-		if p.LastError != nil {
-			t.Errorf("%#v", p.LastError)
+		if res.Restarts != 4 {
+			t.Errorf("invalid restart count %d", res.Restarts)
 		}
-		if p.RestartCount != 4 {
-			t.Errorf("invalid restart count %d", p.RestartCount)
+		if res.Attempts != 4 {
+			t.Errorf("invalid number of attempts %d", res.Attempts)
 		}
 	case <-time.After(5000 * time.Millisecond):
 		t.Fatal("process not stopped", p)
@@ -53,12 +79,12 @@ func TestRestart(t *testing.T) {
 }
 
 func TestStop(t *testing.T) {
-	p := &process.Process{
+	p := &process.Process{Spec: process.Spec{
 		Cmd:          "/bin/sleep",
 		Args:         []string{"3"},
 		StartTimeout: 100,
 		StopTimeout:  1000,
-	}
+	}}
 	res := p.Run(context.TODO())
 	go func() {
 		defer p.Stop()
@@ -66,26 +92,33 @@ func TestStop(t *testing.T) {
 	}()
 
 	select {
-	case err := <-res:
-		if err != nil {
-			t.Fatalf("%+v", err)
-		}
-		_, ok := p.LastError.(*exec.ExitError)
-		if !ok {
-			t.Errorf("%#v", p.LastError)
+	case res := <-res:
+		if res.Err != nil || res.State != process.StateStopped {
+			t.Errorf("%#v", res.Err)
 		}
 	case <-time.After(1500 * time.Millisecond):
 		t.Fatal("process not stopped")
 	}
 }
 
+func TestStopIdle(t *testing.T) {
+	p := process.New("/bin/true")
+	p.Stop()
+	res := <-p.Run(context.TODO())
+	p.Stop()
+	p.Stop()
+	if res.Err != nil || res.State != process.StateStopped {
+		t.Errorf("invalid result: %+v", res)
+	}
+}
+
 func TestCancelContext(t *testing.T) {
-	p := &process.Process{
+	p := &process.Process{Spec: process.Spec{
 		Cmd:          "/bin/sleep",
 		Args:         []string{"3"},
 		StartTimeout: 100,
 		StopTimeout:  1000,
-	}
+	}}
 	ctx, cancel := context.WithCancel(context.TODO())
 	go func() {
 		defer cancel()
@@ -93,15 +126,252 @@ func TestCancelContext(t *testing.T) {
 	}()
 
 	select {
-	case err := <-p.Run(ctx):
-		if err != nil {
-			t.Fatalf("%+v", err)
-		}
-		_, ok := p.LastError.(*exec.ExitError)
-		if !ok {
-			t.Errorf("%#v", p.LastError)
+	case res := <-p.Run(ctx):
+		if res.Err != nil || res.State != process.StateStopped {
+			t.Errorf("%#v", res.Err)
 		}
 	case <-time.After(1500 * time.Millisecond):
 		t.Fatal("process not stopped")
 	}
 }
+
+func TestDoubleRun(t *testing.T) {
+	p := &process.Process{Spec: process.Spec{
+		Cmd:          "/bin/sleep",
+		Args:         []string{"1"},
+		StartTimeout: 100,
+	}}
+	first := p.Run(context.TODO())
+	if res := <-p.Run(context.TODO()); !errors.Is(res.Err, process.ErrAlreadyRunning) {
+		t.Errorf("%#v", res.Err)
+	}
+	if err := p.Reset(); !errors.Is(err, process.ErrAlreadyRunning) {
+		t.Errorf("%#v", err)
+	}
+	if res := <-first; res.Err != nil {
+		t.Fatalf("%+v", res.Err)
+	}
+}
+
+func TestReset(t *testing.T) {
+	p := &process.Process{Spec: process.Spec{
+		Cmd:           "/bin/sh",
+		Args:          []string{"-c", "exit 0"},
+		RestartPolicy: "always",
+		MaxRestarts:   1,
+	}}
+	for i := 0; i < 2; i++ {
+		res := <-p.Run(context.TODO())
+		if res.Restarts != 2 || res.Attempts != 2 {
+			t.Errorf("invalid result on run %d: %+v", i, res)
+		}
+		if err := p.Reset(); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if p.RestartCount != 0 || p.State != process.StateIdle {
+			t.Errorf("process not reset: %+v", p.Status())
+		}
+	}
+}
+
+func TestDetach(t *testing.T) {
+	var stdout syncBuffer
+	p := &process.Process{Spec: process.Spec{
+		Cmd:          "/bin/sh",
+		Args:         []string{"-c", "echo $$ $(ps -o sid= -p $$)"},
+		Stdout:       &stdout,
+		StartTimeout: 1000,
+		Detach:       true,
+	}}
+	<-p.Run(context.TODO())
+	ids := strings.Fields(stdout.String())
+	if len(ids) != 2 || ids[0] != ids[1] {
+		t.Errorf("child is not a session leader: %v", ids)
+	}
+}
+
+func TestSuccessExitCodes(t *testing.T) {
+	spec := process.Spec{
+		Cmd:              "/bin/sh",
+		Args:             []string{"-c", "sleep 0.1; exit 75"},
+		StartTimeout:     10,
+		MaxRestarts:      3,
+		RestartPolicy:    "on-failure",
+		SuccessExitCodes: []int{75},
+	}
+	res := spec.Run(context.TODO()).Wait()
+	if res.Err != nil || res.Restarts != 0 || res.ExitCode != 75 || res.Reason != process.ReasonExited {
+		t.Errorf("invalid result: %+v", res)
+	}
+}
+
+func TestStatePredicates(t *testing.T) {
+	p := &process.Process{Spec: process.Spec{
+		Cmd:          "/bin/sleep",
+		Args:         []string{"0.3"},
+		StartTimeout: 50,
+	}}
+	if p.IsRunning() || p.IsTerminal() || !p.InState(process.StateIdle) {
+		t.Errorf("invalid initial state: %s", p.Status().State)
+	}
+	p.Run(context.TODO())
+	deadline := time.Now().Add(time.Second)
+	for !p.IsRunning() {
+		if time.Now().After(deadline) {
+			t.Fatal("process not running")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := p.Wait(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	if p.IsRunning() || !p.IsTerminal() || !p.InState(process.StateFailed, process.StateStopped) {
+		t.Errorf("invalid final state: %s", p.Status().State)
+	}
+}
+
+func TestLogLevel(t *testing.T) {
+	for level, expected := range map[string]bool{process.LogInfo: true, process.LogError: false} {
+		var stderr syncBuffer
+		p := &process.Process{Spec: process.Spec{
+			Name:     "app",
+			Cmd:      "/bin/true",
+			Stderr:   &stderr,
+			LogLevel: level,
+		}}
+		<-p.Run(context.TODO())
+		if strings.Contains(stderr.String(), " app[") != expected {
+			t.Errorf("invalid %s log: %q", level, stderr.String())
+		}
+	}
+}
+
+func TestLogWarn(t *testing.T) {
+	for level, expected := range map[string]bool{process.LogWarn: true, process.LogError: false} {
+		var stderr syncBuffer
+		p := &process.Process{Spec: process.Spec{
+			Cmd:          "/bin/sleep",
+			Args:         []string{"0.1"},
+			StartTimeout: 10,
+			Stderr:       &stderr,
+			LogLevel:     level,
+			Profiler:     &process.Profiler{Cmd: "/nonexistent", Interval: 20, Dir: t.TempDir()},
+		}}
+		<-p.Run(context.TODO())
+		out := stderr.String()
+		if strings.Contains(out, "profiler:") != expected || strings.Contains(out, "starting") {
+			t.Errorf("invalid %s log: %q", level, out)
+		}
+	}
+}
+
+func TestCancelInState(t *testing.T) {
+	for _, tc := range []struct {
+		state  process.State
+		spec   process.Spec
+		reason process.StopReason
+	}{
+		{process.StateStarting, process.Spec{Cmd: "/bin/sleep", Args: []string{"3"}, StartTimeout: 2000}, process.ReasonCanceled},
+		{process.StateRunning, process.Spec{Cmd: "/bin/sleep", Args: []string{"3"}, StartTimeout: 10}, process.ReasonCanceled},
+		{process.StateBackoff, process.Spec{
+			Cmd: "/bin/false", StartTimeout: 1000, MaxStartAttempts: 3, RestartPolicy: "on-failure", BackoffTimeout: 5000,
+		}, process.ReasonCanceled},
+		{process.StateRestarting, process.Spec{
+			Cmd: "/bin/sh", Args: []string{"-c", "sleep 0.05"}, StartTimeout: 10, RestartPolicy: "always", MaxRestarts: -1, RestartTimeout: 5000,
+		}, process.ReasonCanceled},
+		{process.StateDraining, process.Spec{Cmd: "/bin/sleep", Args: []string{"3"}, StartTimeout: 10, DrainTimeout: 5000}, process.ReasonStopped},
+	} {
+		t.Run(tc.state.String(), func(t *testing.T) {
+			tc.spec.StopTimeout = 1000
+			p := &process.Process{Spec: tc.spec}
+			events, _ := p.Subscribe(nil)
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			start := time.Now()
+			results := p.Run(ctx)
+			for e := range events {
+				switch {
+				case e.State == tc.state:
+					cancel()
+				case e.State == process.StateRunning && tc.state == process.StateDraining:
+					p.Drain()
+				}
+			}
+			res := <-results
+			if res.State != process.StateStopped || res.Reason != tc.reason {
+				t.Errorf("invalid result: %+v", res)
+			}
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("cancel took %v", elapsed)
+			}
+		})
+	}
+}
+
+func TestJitter(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		delays  []time.Duration
+		entered time.Time
+	)
+	p := &process.Process{Spec: process.Spec{
+		Cmd:            "/bin/true",
+		RestartPolicy:  "always",
+		MaxRestarts:    8,
+		StartTimeout:   1000,
+		RestartTimeout: 100,
+		Jitter:         0.5,
+		OnStateChange: func(prev, next process.State, err error, at time.Time) {
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case next == process.StateRestarting:
+				entered = at
+			case prev == process.StateRestarting && next == process.StateStarting:
+				delays = append(delays, at.Sub(entered))
+			}
+		},
+	}}
+	<-p.Run(context.TODO())
+	mu.Lock()
+	defer mu.Unlock()
+	if len(delays) != 8 {
+		t.Fatalf("invalid delays: %v", delays)
+	}
+	min, max := delays[0], delays[0]
+	for _, d := range delays {
+		if d < 50*time.Millisecond || d > 200*time.Millisecond {
+			t.Errorf("delay out of range: %v", d)
+		}
+		if d < min {
+			min = d
+		}
+		if d > max {
+			max = d
+		}
+	}
+	if max-min < 5*time.Millisecond {
+		t.Errorf("delays not spread: %v", delays)
+	}
+	if err := (process.Spec{Cmd: "/bin/true", Jitter: 1.5}).Validate(); err == nil {
+		t.Error("invalid jitter accepted")
+	}
+}
+
+func TestCancelWhileExiting(t *testing.T) {
+	// The child exits at about the time of cancellation, sometimes before
+	// the stop signal is sent
+	for i := 0; i < 50; i++ {
+		p := &process.Process{Spec: process.Spec{
+			Cmd:         "/bin/sh",
+			Args:        []string{"-c", "exec sleep 0.02"},
+			StopTimeout: 1000,
+		}}
+		ctx, cancel := context.WithTimeout(context.TODO(), 20*time.Millisecond)
+		res := <-p.Run(ctx)
+		cancel()
+		if res.State == process.StateFailed || res.Err != nil && res.Reason != process.ReasonCrashed {
+			t.Fatalf("run %d: invalid result: %+v", i, res)
+		}
+	}
+}
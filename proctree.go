@@ -0,0 +1,40 @@
+package process
+
+import (
+	"sync/atomic"
+)
+
+// ProcInfo describes a process in the child process tree
+type ProcInfo struct {
+	Pid     int      `json:"pid"`     // Process ID
+	PPid    int      `json:"ppid"`    // Parent process ID
+	Cmdline []string `json:"cmdline"` // Command line, empty for zombies and kernel threads
+	RSS     int64    `json:"rss"`     // Resident set size in bytes
+}
+
+// Children returns descendants of the running child in the order of a
+// breadth-first walk. The list is empty if the child is not running or the
+// platform process table is not supported (only Linux /proc is).
+func (p *Process) Children() []ProcInfo {
+	pid := int(atomic.LoadInt32(&p.pid))
+	if pid == 0 {
+		return nil
+	}
+	return descendants(listProcs(), pid)
+}
+
+func descendants(procs []ProcInfo, pid int) (res []ProcInfo) {
+	children := make(map[int][]ProcInfo)
+	for _, pi := range procs {
+		children[pi.PPid] = append(children[pi.PPid], pi)
+	}
+	queue := []int{pid}
+	for len(queue) > 0 {
+		for _, pi := range children[queue[0]] {
+			res = append(res, pi)
+			queue = append(queue, pi.Pid)
+		}
+		queue = queue[1:]
+	}
+	return
+}
@@ -0,0 +1,130 @@
+package process
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// listProcs reads the process table from /proc. Processes that exit while
+// being read are skipped.
+func listProcs() (res []ProcInfo) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil
+	}
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		if pi, err := readProcInfo(pid); err == nil {
+			res = append(res, pi)
+		}
+	}
+	return
+}
+
+// runIDProcs returns live processes having the run ID in their environment,
+// that is the child and every process it spawned, including ones detached
+// from the tree
+func runIDProcs(runID string) (res []ProcInfo) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil
+	}
+	marker := []byte("\x00" + RunIDEnv + "=" + runID + "\x00")
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile("/proc/" + e.Name() + "/environ")
+		if err != nil || !bytes.Contains(append([]byte{0}, data...), marker) {
+			continue
+		}
+		if pi, err := readProcInfo(pid); err == nil {
+			res = append(res, pi)
+		}
+	}
+	return
+}
+
+func readProcInfo(pid int) (res ProcInfo, err error) {
+	st, err := readProcStat(pid)
+	if err != nil {
+		return
+	}
+	res = ProcInfo{Pid: pid, PPid: st.ppid, RSS: st.rss * int64(os.Getpagesize())}
+	if data, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/cmdline"); err == nil && len(data) > 0 {
+		res.Cmdline = strings.Split(string(bytes.TrimSuffix(data, []byte{0})), "\x00")
+	}
+	return
+}
+
+// procStat holds the used fields of /proc/<pid>/stat
+type procStat struct {
+	ppid  int
+	utime int64 // In clock ticks
+	stime int64 // In clock ticks
+	rss   int64 // In pages
+}
+
+func readProcStat(pid int) (res procStat, err error) {
+	data, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return
+	}
+	// Command name may contain spaces and parentheses, the fields start
+	// after the last closing one
+	i := bytes.LastIndexByte(data, ')')
+	if i < 0 {
+		return res, os.ErrInvalid
+	}
+	fields := strings.Fields(string(data[i+1:]))
+	// Field numbers of proc(5) minus 3: state is fields[0]
+	if len(fields) < 22 {
+		return res, os.ErrInvalid
+	}
+	if res.ppid, err = strconv.Atoi(fields[1]); err != nil {
+		return
+	}
+	if res.utime, err = strconv.ParseInt(fields[11], 10, 64); err != nil {
+		return
+	}
+	if res.stime, err = strconv.ParseInt(fields[12], 10, 64); err != nil {
+		return
+	}
+	res.rss, err = strconv.ParseInt(fields[21], 10, 64)
+	return
+}
+
+// clockTicks is USER_HZ, which is 100 on all Linux architectures
+const clockTicks = 100
+
+// cpuTime returns CPU time consumed by the process
+func cpuTime(pid int) (time.Duration, error) {
+	st, err := readProcStat(pid)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(st.utime+st.stime) * time.Second / clockTicks, nil
+}
+
+// fdUsage returns the number of open files of the process and its soft limit
+func fdUsage(pid int) (count int, limit uint64, err error) {
+	var lim syscall.Rlimit
+	if err = prlimit(pid, syscall.RLIMIT_NOFILE, nil, &lim); err != nil {
+		return
+	}
+	f, err := os.Open("/proc/" + strconv.Itoa(pid) + "/fd")
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	names, err := f.Readdirnames(-1)
+	return len(names), lim.Cur, err
+}
@@ -0,0 +1,24 @@
+//go:build !linux
+
+package process
+
+import (
+	"errors"
+	"time"
+)
+
+func listProcs() []ProcInfo {
+	return nil
+}
+
+func runIDProcs(runID string) []ProcInfo {
+	return nil
+}
+
+func cpuTime(pid int) (time.Duration, error) {
+	return 0, errors.New("CPU time is not supported on this platform")
+}
+
+func fdUsage(pid int) (int, uint64, error) {
+	return 0, 0, errors.New("open files count is not supported on this platform")
+}
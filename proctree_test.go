@@ -0,0 +1,39 @@
+package process_test
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/andviro/process"
+)
+
+func TestChildren(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("process table is only supported on Linux")
+	}
+	p := &process.Process{Spec: process.Spec{
+		Cmd:          "/bin/sh",
+		Args:         []string{"-c", "sh -c 'sleep 3; true' & sleep 3; wait"},
+		StartTimeout: 100,
+		StopTimeout:  10,
+		KillTimeout:  1000,
+	}}
+	if children := p.Children(); children != nil {
+		t.Errorf("children of idle process: %+v", children)
+	}
+	ctx, cancel := context.WithCancel(context.TODO())
+	res := p.Run(ctx)
+	time.Sleep(200 * time.Millisecond)
+	pid, children := p.Status().Pid, p.Children()
+	cancel()
+	<-res
+	if len(children) != 3 {
+		t.Fatalf("invalid children: %+v", children)
+	}
+	if children[0].PPid != pid || children[1].PPid != pid || children[2].PPid == pid ||
+		children[2].Cmdline[0] != "sleep" || children[2].RSS == 0 {
+		t.Errorf("invalid children: %+v (child pid %d)", children, pid)
+	}
+}
@@ -0,0 +1,102 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Profiler periodically runs a diagnostic command against the child and
+// archives its output, e.g. "jstack {pid}", "py-spy dump --pid {pid}" or
+// "kill -QUIT {pid}" for children dumping stacks on a signal. The samples
+// are kept when the child is restarted by a watchdog.
+type Profiler struct {
+	Cmd      string   `json:"cmd"`      // Path to the profiler executable
+	Args     []string `json:"args"`     // Command-line arguments, "{pid}" is replaced with the child pid
+	Interval int      `json:"interval"` // Time between samples in milliseconds
+	Timeout  int      `json:"timeout"`  // Time limit of a single sample in milliseconds, Interval if zero
+	Dir      string   `json:"dir"`      // Directory for samples, one subdirectory per run ID
+	Keep     int      `json:"keep"`     // Number of the newest samples kept per run, all if zero
+}
+
+func (pr Profiler) validate() error {
+	switch {
+	case pr.Cmd == "":
+		return fmt.Errorf("cmd must be set")
+	case pr.Dir == "":
+		return fmt.Errorf("dir must be set")
+	case pr.Interval <= 0:
+		return fmt.Errorf("interval must be positive")
+	case pr.Timeout < 0:
+		return fmt.Errorf("timeout must not be negative")
+	case pr.Keep < 0:
+		return fmt.Errorf("keep must not be negative")
+	}
+	return nil
+}
+
+// sampleProfiles runs the profiler every Interval until done is closed
+func (p *Process) sampleProfiles(pid int, runID string, done <-chan struct{}) {
+	pr := *p.Profiler
+	dir := filepath.Join(pr.Dir, runID)
+	if err := os.MkdirAll(dir, p.dirMode()); err != nil {
+		p.warnf("profiler: %v", err)
+		return
+	}
+	ticker := time.NewTicker(time.Duration(pr.Interval) * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+		if err := pr.sample(pid, dir); err != nil {
+			p.warnf("profiler: %v", err)
+		}
+	}
+}
+
+// sample writes the combined profiler output to a file named after the
+// sample time and removes the samples exceeding Keep
+func (pr Profiler) sample(pid int, dir string) error {
+	timeout := pr.Timeout
+	if timeout == 0 {
+		timeout = pr.Interval
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Millisecond)
+	defer cancel()
+	args := make([]string, len(pr.Args))
+	for i, arg := range pr.Args {
+		args[i] = strings.ReplaceAll(arg, "{pid}", strconv.Itoa(pid))
+	}
+	out, err := exec.CommandContext(ctx, pr.Cmd, args...).CombinedOutput()
+	name := filepath.Join(dir, time.Now().UTC().Format("20060102T150405.000000000")+".txt")
+	if werr := os.WriteFile(name, out, 0644); werr != nil {
+		return werr
+	}
+	if err != nil {
+		return err
+	}
+	if pr.Keep == 0 {
+		return nil
+	}
+	samples, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(samples)
+	for len(samples) > pr.Keep {
+		if err := os.Remove(samples[0]); err != nil {
+			return err
+		}
+		samples = samples[1:]
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package process_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/andviro/process"
+)
+
+func TestProfiler(t *testing.T) {
+	dir := t.TempDir()
+	p := &process.Process{Spec: process.Spec{
+		Cmd:          "/bin/sleep",
+		Args:         []string{"1"},
+		StartTimeout: 10,
+		StopTimeout:  1000,
+		Profiler: &process.Profiler{
+			Cmd:      "/bin/echo",
+			Args:     []string{"pid={pid}"},
+			Interval: 20,
+			Dir:      dir,
+			Keep:     3,
+		},
+	}}
+	ctx, cancel := context.WithTimeout(context.TODO(), 300*time.Millisecond)
+	defer cancel()
+	events, _ := p.Subscribe(func(e process.Event) bool { return e.State == process.StateRunning })
+	res := p.Run(ctx)
+	<-events
+	pid := p.Status().Pid
+	r := <-res
+	// Let the sample started before the exit finish
+	time.Sleep(50 * time.Millisecond)
+	samples, _ := filepath.Glob(filepath.Join(dir, r.RunID, "*.txt"))
+	if len(samples) != 3 {
+		t.Fatalf("invalid samples: %v", samples)
+	}
+	data, _ := os.ReadFile(samples[2])
+	if string(data) != "pid="+strconv.Itoa(pid)+"\n" {
+		t.Errorf("invalid sample: %q", data)
+	}
+	if err := (process.Spec{Cmd: "/bin/true", Profiler: &process.Profiler{Cmd: "jstack"}}).Validate(); err == nil {
+		t.Error("invalid profiler accepted")
+	}
+}
@@ -0,0 +1,61 @@
+package process
+
+import (
+	"os"
+	"strconv"
+	"syscall"
+	"unsafe"
+)
+
+// openPTY allocates a pseudo-terminal pair like posix_openpt, grantpt and
+// unlockpt do. The master is pollable, so its reads can be interrupted.
+func openPTY() (master, slave *os.File, err error) {
+	if master, err = os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0); err != nil {
+		return nil, nil, err
+	}
+	var n uint32
+	if err = control(master, func(fd uintptr) error {
+		var unlock int32
+		if err := ioctl(fd, syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock))); err != nil {
+			return err
+		}
+		return ioctl(fd, syscall.TIOCGPTN, uintptr(unsafe.Pointer(&n)))
+	}); err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+	if slave, err = os.OpenFile("/dev/pts/"+strconv.Itoa(int(n)), os.O_RDWR|syscall.O_NOCTTY, 0); err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+	return master, slave, nil
+}
+
+// setWinsize sets the window size of the terminal, the foreground process
+// group of the terminal receives SIGWINCH
+func setWinsize(f *os.File, rows, cols int) error {
+	ws := struct{ Row, Col, Xpixel, Ypixel uint16 }{Row: uint16(rows), Col: uint16(cols)}
+	return control(f, func(fd uintptr) error {
+		return ioctl(fd, syscall.TIOCSWINSZ, uintptr(unsafe.Pointer(&ws)))
+	})
+}
+
+// control calls fn with the descriptor of the file without switching it to
+// blocking mode like Fd does
+func control(f *os.File, fn func(fd uintptr) error) error {
+	rc, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	if cerr := rc.Control(func(fd uintptr) { err = fn(fd) }); cerr != nil {
+		return cerr
+	}
+	return err
+}
+
+func ioctl(fd, req, arg uintptr) error {
+	if _, _, e := syscall.Syscall(syscall.SYS_IOCTL, fd, req, arg); e != 0 {
+		return e
+	}
+	return nil
+}
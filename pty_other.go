@@ -0,0 +1,18 @@
+//go:build !linux
+
+package process
+
+import (
+	"errors"
+	"os"
+)
+
+var errNoPTY = errors.New("pty is not supported on this platform")
+
+func openPTY() (master, slave *os.File, err error) {
+	return nil, nil, errNoPTY
+}
+
+func setWinsize(f *os.File, rows, cols int) error {
+	return errNoPTY
+}
@@ -0,0 +1,100 @@
+package process
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// RateLimitWriter passes through at most Lines lines per second to the
+// underlying writer. Suppressed lines are reported with a marker line when
+// the next line passes, the one-second window is over or the writer is
+// flushed. Partial lines are held until the newline or Flush. The writer is
+// created with NewRateLimitWriter, the zero value discards the output.
+type RateLimitWriter struct {
+	Lines  int // Maximum number of lines per second
+	Sample int // Pass every Sample-th line over the limit, no sampling if 0
+
+	mu         sync.Mutex
+	w          io.Writer
+	lw         lineWriter
+	window     time.Time
+	passed     int
+	over       int
+	suppressed int
+	pending    bool // Marker is scheduled for the end of the window
+	err        error
+}
+
+// NewRateLimitWriter creates a writer limiting the output to lines per second
+func NewRateLimitWriter(w io.Writer, lines int) *RateLimitWriter {
+	return &RateLimitWriter{Lines: lines, w: w}
+}
+
+func (rw *RateLimitWriter) Write(b []byte) (int, error) {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	if rw.lw.fn == nil {
+		rw.lw.fn = rw.line
+	}
+	rw.err = nil
+	n, _ := rw.lw.Write(b)
+	return n, rw.err
+}
+
+// Flush writes the held partial line and the marker of suppressed lines
+func (rw *RateLimitWriter) Flush() error {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	rw.err = nil
+	if rw.lw.fn != nil {
+		rw.lw.Flush()
+	}
+	rw.report()
+	return rw.err
+}
+
+func (rw *RateLimitWriter) line(line []byte) {
+	now := time.Now()
+	if now.Sub(rw.window) >= time.Second {
+		rw.window, rw.passed, rw.over = now, 0, 0
+	}
+	if rw.passed >= rw.Lines {
+		rw.over++
+		if rw.Sample <= 0 || rw.over%rw.Sample != 0 {
+			rw.suppressed++
+			if !rw.pending {
+				rw.pending = true
+				time.AfterFunc(rw.window.Add(time.Second).Sub(now), rw.rollover)
+			}
+			return
+		}
+	} else {
+		rw.passed++
+	}
+	rw.report()
+	rw.write(line)
+}
+
+// rollover reports lines suppressed until the end of the window
+func (rw *RateLimitWriter) rollover() {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	rw.pending = false
+	rw.report()
+}
+
+func (rw *RateLimitWriter) report() {
+	if rw.suppressed > 0 {
+		rw.write([]byte(fmt.Sprintf("[%d lines suppressed]", rw.suppressed)))
+		rw.suppressed = 0
+	}
+}
+
+func (rw *RateLimitWriter) write(line []byte) {
+	if rw.err != nil || rw.w == nil {
+		return
+	}
+	_, rw.err = rw.w.Write(append(line[:len(line):len(line)], '\n'))
+}
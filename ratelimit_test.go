@@ -0,0 +1,61 @@
+package process_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andviro/process"
+)
+
+func TestRateLimitWriter(t *testing.T) {
+	var buf syncBuffer
+	w := process.NewRateLimitWriter(&buf, 3)
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(w, "line %d\n", i)
+	}
+	fmt.Fprint(w, "partial ")
+	time.Sleep(1100 * time.Millisecond)
+	fmt.Fprint(w, "line\n")
+	expected := "line 0\nline 1\nline 2\n[7 lines suppressed]\npartial line\n"
+	if out := buf.String(); out != expected {
+		t.Errorf("invalid output: %q", out)
+	}
+}
+
+func TestRateLimitWriterSample(t *testing.T) {
+	var buf syncBuffer
+	w := process.NewRateLimitWriter(&buf, 2)
+	w.Sample = 4
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(w, "line %d\n", i)
+	}
+	expected := []string{"line 0", "line 1", "[3 lines suppressed]", "line 5", "[3 lines suppressed]", "line 9"}
+	if out := strings.Split(strings.TrimSpace(buf.String()), "\n"); strings.Join(out, ",") != strings.Join(expected, ",") {
+		t.Errorf("invalid output: %q", out)
+	}
+}
+
+func TestRateLimitWriterTrailing(t *testing.T) {
+	var buf syncBuffer
+	w := process.NewRateLimitWriter(&buf, 1)
+	fmt.Fprint(w, "line 0\nline 1\nline 2\n")
+	if out := buf.String(); out != "line 0\n" {
+		t.Errorf("invalid output: %q", out)
+	}
+	time.Sleep(1100 * time.Millisecond)
+	if out := buf.String(); out != "line 0\n[2 lines suppressed]\n" {
+		t.Errorf("suppression not reported on rollover: %q", out)
+	}
+	fmt.Fprint(w, "line 3\nline 4\npartial")
+	w.Flush()
+	if out := buf.String(); !strings.HasSuffix(out, "line 3\n[2 lines suppressed]\n") {
+		t.Errorf("suppression not reported on flush: %q", out)
+	}
+
+	var zero process.RateLimitWriter
+	if _, err := fmt.Fprint(&zero, "line\n"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,111 @@
+package process
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Running confirmation modes
+const (
+	ReadyAfterTimeout = "timeout" // Child is running if it's alive after StartTimeout
+	ReadyOnPattern    = "pattern" // Child prints a line matching ReadyPattern to stdout
+	ReadyOnProbe      = "probe"   // ReadyProbe returns true
+	ReadyOnNotify     = "notify"  // Child sends READY=1 to $NOTIFY_SOCKET like with systemd Type=notify
+)
+
+// NotifySocketEnv is the name of environment variable holding the path of
+// the readiness notification socket
+const NotifySocketEnv = "NOTIFY_SOCKET"
+
+const readyProbeInterval = 100 * time.Millisecond
+
+// readyMode returns the running confirmation mode, ReadyPattern implies
+// pattern mode
+func (s Spec) readyMode() string {
+	switch {
+	case s.ReadyMode != "":
+		return s.ReadyMode
+	case s.ReadyPattern != "":
+		return ReadyOnPattern
+	}
+	return ReadyAfterTimeout
+}
+
+func (s Spec) validateReady() error {
+	switch s.readyMode() {
+	case ReadyAfterTimeout, ReadyOnNotify:
+	case ReadyOnPattern:
+		if s.ReadyPattern == "" {
+			return fmt.Errorf("readyPattern must be set")
+		}
+	case ReadyOnProbe:
+		if s.ReadyProbe == nil {
+			return fmt.Errorf("readyProbe must be set")
+		}
+	default:
+		return fmt.Errorf("unknown mode %q", s.ReadyMode)
+	}
+	return nil
+}
+
+// probeReady polls ReadyProbe until it succeeds or done is closed
+func (p *Process) probeReady(ready chan struct{}, done <-chan struct{}) {
+	t := time.NewTicker(readyProbeInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-t.C:
+		}
+		if p.ReadyProbe() {
+			close(ready)
+			return
+		}
+	}
+}
+
+// notifySocket receives readiness notifications from the child
+type notifySocket struct {
+	conn *net.UnixConn
+	path string
+}
+
+func listenNotify(runID string) (*notifySocket, error) {
+	path := filepath.Join(os.TempDir(), "process-"+runID+".sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+	return &notifySocket{conn: conn, path: path}, nil
+}
+
+// wait closes ready on READY=1 notification, the socket is removed once the
+// child is ready or done is closed
+func (s *notifySocket) wait(ready chan struct{}, done <-chan struct{}) {
+	defer os.Remove(s.path)
+	go func() {
+		select {
+		case <-done:
+		case <-ready:
+		}
+		s.conn.Close()
+	}()
+	buf := make([]byte, 4096)
+	for {
+		n, _, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		for _, line := range strings.Split(string(buf[:n]), "\n") {
+			if line == "READY=1" {
+				close(ready)
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package process_test
+
+import (
+	"context"
+	"net"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/andviro/process"
+)
+
+func TestReadyProbe(t *testing.T) {
+	var probes int32
+	p := &process.Process{Spec: process.Spec{
+		Cmd:          "/bin/sh",
+		Args:         []string{"-c", "exec sleep 1"},
+		ReadyMode:    process.ReadyOnProbe,
+		ReadyProbe:   func() bool { return atomic.AddInt32(&probes, 1) == 3 },
+		StartTimeout: 1000,
+		StopTimeout:  1000,
+	}}
+	ctx, cancel := context.WithCancel(context.TODO())
+	res := p.Run(ctx)
+	time.Sleep(200 * time.Millisecond)
+	if st := p.Status().State; st != process.StateStarting {
+		t.Errorf("invalid state before ready: %v", st)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if st := p.Status().State; st != process.StateRunning {
+		t.Errorf("invalid state after ready: %v", st)
+	}
+	cancel()
+	<-res
+}
+
+// TestNotifyHelper is run as a child by TestReadyNotify
+func TestNotifyHelper(t *testing.T) {
+	path := os.Getenv(process.NotifySocketEnv)
+	if os.Getenv("PROCESS_TEST_NOTIFY") != "1" || path == "" {
+		t.Skip("helper process")
+	}
+	time.Sleep(100 * time.Millisecond)
+	conn, err := net.Dial("unixgram", path)
+	if err != nil {
+		os.Exit(2)
+	}
+	conn.Write([]byte("STATUS=starting\nREADY=1\n"))
+	conn.Close()
+	time.Sleep(time.Second)
+	os.Exit(0)
+}
+
+func TestReadyNotify(t *testing.T) {
+	p := &process.Process{Spec: process.Spec{
+		Cmd:          os.Args[0],
+		Args:         []string{"-test.run=^TestNotifyHelper$"},
+		Env:          append(os.Environ(), "PROCESS_TEST_NOTIFY=1"),
+		ReadyMode:    process.ReadyOnNotify,
+		StartTimeout: 1000,
+		StopTimeout:  1000,
+	}}
+	ctx, cancel := context.WithCancel(context.TODO())
+	res := p.Run(ctx)
+	time.Sleep(50 * time.Millisecond)
+	if st := p.Status().State; st != process.StateStarting {
+		t.Errorf("invalid state before ready: %v", st)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if st := p.Status().State; st != process.StateRunning {
+		t.Errorf("invalid state after ready: %v", st)
+	}
+	cancel()
+	<-res
+}
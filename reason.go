@@ -0,0 +1,39 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// StopReason tells why supervision of the process has finished
+type StopReason string
+
+// Stop reasons
+const (
+	ReasonExited    StopReason = "exited"    // Child exited successfully and was not restarted
+	ReasonCrashed   StopReason = "crashed"   // Child exited with error and was not restarted
+	ReasonCanceled  StopReason = "canceled"  // Run context was cancelled
+	ReasonStopped   StopReason = "stopped"   // Stop or Drain was called
+	ReasonExhausted StopReason = "exhausted" // Start attempts or restarts limit was reached
+	ReasonFailed    StopReason = "failed"    // Process could not be started or killed
+)
+
+// stopReason infers the reason from the final state of the run
+func (p *Process) stopReason(ctx context.Context) StopReason {
+	switch {
+	case errors.Is(p.LastError, ErrMaxStartAttempts) || errors.Is(p.LastError, ErrMaxRestarts):
+		return ReasonExhausted
+	case p.State == StateFailed || p.State == StatePreflightFailed:
+		return ReasonFailed
+	case atomic.LoadInt32(&p.stopCalled) != 0 || p.isDraining():
+		return ReasonStopped
+	case ctx.Err() != nil:
+		return ReasonCanceled
+	case p.MaxRestarts != -1 && p.RestartCount > p.MaxRestarts:
+		return ReasonExhausted
+	case p.LastError != nil:
+		return ReasonCrashed
+	}
+	return ReasonExited
+}
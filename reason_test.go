@@ -0,0 +1,72 @@
+package process_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/andviro/process"
+)
+
+func TestStopReason(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		script string
+		spec   func(*process.Spec)
+		run    func(*process.Handle)
+		reason process.StopReason
+	}{
+		{name: "exited", script: "sleep 0.1", reason: process.ReasonExited},
+		{name: "crashed", script: "sleep 0.1; exit 1", reason: process.ReasonCrashed},
+		{
+			name:   "exhausted",
+			script: "sleep 0.1; exit 1",
+			spec: func(s *process.Spec) {
+				s.MaxRestarts = 1
+				s.RestartPolicy = "on-failure"
+			},
+			reason: process.ReasonExhausted,
+		},
+		{name: "failed", spec: func(s *process.Spec) { s.Cmd = "/nonexistent" }, reason: process.ReasonFailed},
+		{name: "stopped", script: "exec sleep 3", run: func(h *process.Handle) { h.Stop() }, reason: process.ReasonStopped},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			spec := process.Spec{
+				Cmd:          "/bin/sh",
+				Args:         []string{"-c", tc.script},
+				StartTimeout: 10,
+				StopTimeout:  1000,
+			}
+			if tc.spec != nil {
+				tc.spec(&spec)
+			}
+			h := spec.Run(context.TODO())
+			for e := range h.Events() {
+				if e.State == process.StateRunning && tc.run != nil {
+					tc.run(h)
+				}
+			}
+			res := h.Wait()
+			if res.Reason != tc.reason {
+				t.Errorf("invalid reason: %s (%+v)", res.Reason, res)
+			}
+			if st := h.Status(); st.Reason != tc.reason {
+				t.Errorf("invalid status reason: %s", st.Reason)
+			}
+		})
+	}
+}
+
+func TestStopReasonCanceled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.TODO(), 200*time.Millisecond)
+	defer cancel()
+	spec := process.Spec{
+		Cmd:          "/bin/sleep",
+		Args:         []string{"3"},
+		StartTimeout: 10,
+		StopTimeout:  1000,
+	}
+	if res := spec.Run(ctx).Wait(); res.Reason != process.ReasonCanceled {
+		t.Errorf("invalid reason: %s", res.Reason)
+	}
+}
@@ -0,0 +1,33 @@
+package process
+
+// Reconcile checks pids persisted in the store against the live process
+// table. Records of children that are still running are returned so that
+// they are not started again, see Adopt for watching and stopping them. Pids
+// of the others are cleared in the store.
+//
+// Where the platform allows, a live pid is only considered ours when the
+// process carries the recorded run ID in its environment, so that a pid
+// reused by an unrelated process is not mistaken for the child.
+func Reconcile(s Store) (live []Record, err error) {
+	records, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range records {
+		if r.Pid == 0 {
+			continue
+		}
+		if processAlive(r.Pid, r.RunID) {
+			live = append(live, r)
+			continue
+		}
+		r.Pid = 0
+		if r.State == StateRunning || r.State == StateStopping || r.State == StateKilling {
+			r.State = StateStopped
+		}
+		if err := s.Save(r); err != nil {
+			return live, err
+		}
+	}
+	return live, nil
+}
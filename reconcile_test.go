@@ -0,0 +1,68 @@
+package process_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/andviro/process"
+)
+
+func TestReconcile(t *testing.T) {
+	s, err := process.OpenFileStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &process.Process{Spec: process.Spec{
+		Cmd:          "/bin/sh",
+		Args:         []string{"-c", "exec sleep 1"},
+		StartTimeout: 50,
+		StopTimeout:  1000,
+	}}
+	ctx, cancel := context.WithCancel(context.TODO())
+	res := p.Run(ctx)
+	time.Sleep(100 * time.Millisecond)
+	stale := p.Record("stale")
+	cancel()
+	<-res
+	if err := s.Save(stale); err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS == "linux" {
+		if err := s.Save(process.Record{Name: "reused", Pid: os.Getpid(), RunID: "0000", State: process.StateRunning}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p = &process.Process{Spec: p.Spec}
+	ctx, cancel = context.WithCancel(context.TODO())
+	defer cancel()
+	res = p.Run(ctx)
+	time.Sleep(100 * time.Millisecond)
+	live := p.Record("live")
+	if err := s.Save(live); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := process.Reconcile(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Name != "live" || records[0].Pid != live.Pid {
+		t.Errorf("invalid live records: %+v", records)
+	}
+	for _, name := range []string{"stale", "reused"} {
+		r, err := s.Load(name)
+		if err != nil {
+			continue
+		}
+		if r.Pid != 0 || r.State == process.StateRunning {
+			t.Errorf("stale record %s not cleaned: %+v", name, r)
+		}
+	}
+	cancel()
+	<-res
+}
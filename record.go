@@ -0,0 +1,111 @@
+package process
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// castWriter records a terminal session in asciicast v2 format: a header
+// line followed by [time, code, data] events of output ("o"), input ("i")
+// and resize ("r")
+type castWriter struct {
+	mu    sync.Mutex
+	f     *os.File
+	start time.Time
+	tail  []byte // Incomplete UTF-8 sequence at the end of the last output
+	err   error
+}
+
+// openCast creates a new recording in the directory named after the run ID
+func openCast(dir, runID, title string, rows, cols int, mode os.FileMode) (*castWriter, error) {
+	if err := os.MkdirAll(dir, mode); err != nil {
+		return nil, err
+	}
+	var (
+		f   *os.File
+		err error
+	)
+	// A child resumed after Supervisor.Reexec keeps its run ID
+	for i := 0; f == nil; i++ {
+		name := runID + ".cast"
+		if i > 0 {
+			name = runID + "." + strconv.Itoa(i) + ".cast"
+		}
+		f, err = os.OpenFile(filepath.Join(dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err != nil && !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+	}
+	c := &castWriter{f: f, start: time.Now()}
+	header, _ := json.Marshal(struct {
+		Version   int               `json:"version"`
+		Width     int               `json:"width"`
+		Height    int               `json:"height"`
+		Timestamp int64             `json:"timestamp"`
+		Title     string            `json:"title,omitempty"`
+		Env       map[string]string `json:"env,omitempty"`
+	}{2, cols, rows, c.start.Unix(), title, map[string]string{"TERM": os.Getenv("TERM")}})
+	if _, err = f.Write(append(header, '\n')); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Write records the output. Events are split on UTF-8 boundaries, since
+// their data are JSON strings.
+func (c *castWriter) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data := append(c.tail, p...)
+	c.tail = nil
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				data, c.tail = data[:i], append([]byte(nil), data[i:]...)
+			}
+			break
+		}
+	}
+	if len(data) != 0 {
+		c.event("o", string(data))
+	}
+	return len(p), nil
+}
+
+// input records the input of an attached session
+func (c *castWriter) input(p []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.event("i", string(p))
+}
+
+func (c *castWriter) resize(rows, cols int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.event("r", strconv.Itoa(cols)+"x"+strconv.Itoa(rows))
+}
+
+// event writes the event line, the recording stops at the first error
+func (c *castWriter) event(code, data string) {
+	if c.err != nil {
+		return
+	}
+	line, _ := json.Marshal([]interface{}{time.Since(c.start).Seconds(), code, data})
+	_, c.err = c.f.Write(append(line, '\n'))
+}
+
+func (c *castWriter) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.tail) != 0 {
+		c.event("o", string(c.tail))
+	}
+	return c.f.Close()
+}
@@ -0,0 +1,59 @@
+package process
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// registry maps names to processes registered for lookup from anywhere in
+// the program
+var registry struct {
+	mu    sync.RWMutex
+	procs map[string]*Process
+}
+
+// Register adds the process to the package registry under its Name, or the
+// base name of Cmd if Name is empty
+func Register(p *Process) error {
+	name := p.procName()
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if registry.procs == nil {
+		registry.procs = make(map[string]*Process)
+	}
+	if _, ok := registry.procs[name]; ok {
+		return fmt.Errorf("%w: %q", ErrDuplicateName, name)
+	}
+	registry.procs[name] = p
+	return nil
+}
+
+// Unregister removes the process from the package registry
+func Unregister(p *Process) {
+	name := p.procName()
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if registry.procs[name] == p {
+		delete(registry.procs, name)
+	}
+}
+
+// Lookup returns the registered process with the name or nil
+func Lookup(name string) *Process {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	return registry.procs[name]
+}
+
+// Registered returns sorted names of the registered processes
+func Registered() []string {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	res := make([]string, 0, len(registry.procs))
+	for name := range registry.procs {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,30 @@
+package process_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/andviro/process"
+)
+
+func TestRegistry(t *testing.T) {
+	p := process.New("/bin/sleep", "1")
+	p.Name = "registry-test"
+	if err := process.Register(p); err != nil {
+		t.Fatal(err)
+	}
+	defer process.Unregister(p)
+	if err := process.Register(&process.Process{Spec: p.Spec}); !errors.Is(err, process.ErrDuplicateName) {
+		t.Errorf("%+v", err)
+	}
+	if process.Lookup("registry-test") != p {
+		t.Error("process not found")
+	}
+	if names := process.Registered(); len(names) != 1 || names[0] != "registry-test" {
+		t.Errorf("invalid names: %v", names)
+	}
+	process.Unregister(p)
+	if process.Lookup("registry-test") != nil {
+		t.Error("process not unregistered")
+	}
+}
@@ -0,0 +1,18 @@
+package process
+
+// request is an action asynchronously requested from the running state
+type request struct {
+	restart bool   // Restart the process regardless of the restart policy
+	err     error  // Stop the process as failed with the error
+	msg     string // Log message
+}
+
+type requests chan request
+
+// send delivers the request unless there's one pending already
+func (r requests) send(req request) {
+	select {
+	case r <- req:
+	default:
+	}
+}
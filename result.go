@@ -0,0 +1,37 @@
+package process
+
+import (
+	"time"
+)
+
+// RunResult describes the outcome of a completed Run
+type RunResult struct {
+	State     State      `json:"state"`     // Final process state
+	ExitCode  int        `json:"exitCode"`  // Exit code of the last run, -1 if the process was never started or was killed by a signal
+	Attempts  int        `json:"attempts"`  // Total number of start attempts
+	Restarts  int        `json:"restarts"`  // Number of restarts
+	RunID     string     `json:"runId"`     // Identifier of the last start attempt
+	StartedAt time.Time  `json:"startedAt"` // Time when Run was called
+	StoppedAt time.Time  `json:"stoppedAt"` // Time when supervision has finished
+	Err       error      `json:"err"`       // Last error encountered
+	Reason    StopReason `json:"reason"`    // Why supervision has finished
+	Output    []byte     `json:"output"`    // Tail of the combined output of the last start attempt if CaptureOutput is set
+}
+
+func (p *Process) runResult(startedAt time.Time, err error) (res RunResult) {
+	res = RunResult{
+		State:     p.State,
+		ExitCode:  p.exitCode,
+		Attempts:  p.starts,
+		Restarts:  p.RestartCount,
+		RunID:     p.RunID,
+		StartedAt: startedAt,
+		StoppedAt: time.Now(),
+		Err:       p.LastError,
+		Output:    p.capture.Bytes(),
+	}
+	if err != nil {
+		res.Err = err
+	}
+	return
+}
@@ -0,0 +1,178 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	serviceWin32OwnProcess = 0x10
+
+	serviceStopped     = 1
+	serviceStopPending = 3
+	serviceRunning     = 4
+	servicePaused      = 7
+
+	serviceControlStop        = 1
+	serviceControlPause       = 2
+	serviceControlContinue    = 3
+	serviceControlInterrogate = 4
+	serviceControlShutdown    = 5
+
+	serviceAcceptStop          = 1
+	serviceAcceptPauseContinue = 2
+	serviceAcceptShutdown      = 4
+
+	errorCallNotImplemented   = 120
+	errorServiceSpecificError = 1066
+)
+
+var (
+	procStartServiceCtrlDispatcherW   = advapi32.NewProc("StartServiceCtrlDispatcherW")
+	procRegisterServiceCtrlHandlerExW = advapi32.NewProc("RegisterServiceCtrlHandlerExW")
+	procSetServiceStatus              = advapi32.NewProc("SetServiceStatus")
+)
+
+// Service describes a program run by the Windows service control manager
+type Service struct {
+	Name     string                          // Service name as registered with the SCM
+	Run      func(ctx context.Context) error // Service main loop, ctx is cancelled on Stop and Shutdown
+	Pause    func() error                    // Called on Pause control, pausing is not accepted if nil
+	Continue func() error                    // Called on Continue control
+}
+
+// PauseProcesses returns Pause and Continue functions suspending and resuming
+// all the processes
+func PauseProcesses(procs ...*Process) (pause, resume func() error) {
+	each := func(fn func(*Process) error) func() error {
+		return func() (err error) {
+			for _, p := range procs {
+				if e := fn(p); e != nil && !errors.Is(e, ErrNotRunning) && err == nil {
+					err = e
+				}
+			}
+			return
+		}
+	}
+	return each((*Process).Pause), each((*Process).Resume)
+}
+
+type serviceStatus struct {
+	serviceType             uint32
+	currentState            uint32
+	controlsAccepted        uint32
+	win32ExitCode           uint32
+	serviceSpecificExitCode uint32
+	checkPoint              uint32
+	waitHint                uint32
+}
+
+type serviceTableEntry struct {
+	name *uint16
+	proc uintptr
+}
+
+// service holds the state of the only service a process can run
+var service struct {
+	mu     sync.Mutex
+	svc    Service
+	handle uintptr
+	status serviceStatus
+	cancel context.CancelFunc
+	err    error
+}
+
+var (
+	serviceMainCallback    = syscall.NewCallback(serviceMain)
+	serviceHandlerCallback = syscall.NewCallback(serviceHandler)
+)
+
+// RunService runs the service under the service control manager and blocks
+// until it stops. It fails if the program was not started by the SCM.
+func RunService(svc Service) error {
+	name, err := syscall.UTF16PtrFromString(svc.Name)
+	if err != nil {
+		return err
+	}
+	service.mu.Lock()
+	service.svc, service.err = svc, nil
+	service.mu.Unlock()
+	table := []serviceTableEntry{{name: name, proc: serviceMainCallback}, {}}
+	if r, _, err := procStartServiceCtrlDispatcherW.Call(uintptr(unsafe.Pointer(&table[0]))); r == 0 {
+		return err
+	}
+	service.mu.Lock()
+	defer service.mu.Unlock()
+	return service.err
+}
+
+func serviceMain(argc, argv uintptr) uintptr {
+	service.mu.Lock()
+	svc := service.svc
+	name, _ := syscall.UTF16PtrFromString(svc.Name)
+	h, _, err := procRegisterServiceCtrlHandlerExW.Call(uintptr(unsafe.Pointer(name)), serviceHandlerCallback, 0)
+	if h == 0 {
+		service.err = err
+		service.mu.Unlock()
+		return 0
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	service.handle, service.cancel = h, cancel
+	accepted := uint32(serviceAcceptStop | serviceAcceptShutdown)
+	if svc.Pause != nil && svc.Continue != nil {
+		accepted |= serviceAcceptPauseContinue
+	}
+	service.status = serviceStatus{
+		serviceType:      serviceWin32OwnProcess,
+		currentState:     serviceRunning,
+		controlsAccepted: accepted,
+	}
+	setServiceStatus()
+	service.mu.Unlock()
+
+	err = svc.Run(ctx)
+	cancel()
+
+	service.mu.Lock()
+	defer service.mu.Unlock()
+	service.err = err
+	service.status.currentState = serviceStopped
+	service.status.controlsAccepted = 0
+	if err != nil {
+		service.status.win32ExitCode = errorServiceSpecificError
+		service.status.serviceSpecificExitCode = 1
+	}
+	setServiceStatus()
+	return 0
+}
+
+func serviceHandler(control, _, _, _ uintptr) uintptr {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+	switch control {
+	case serviceControlStop, serviceControlShutdown:
+		service.status.currentState = serviceStopPending
+		service.cancel()
+	case serviceControlPause:
+		if service.svc.Pause == nil || service.svc.Pause() == nil {
+			service.status.currentState = servicePaused
+		}
+	case serviceControlContinue:
+		if service.svc.Continue == nil || service.svc.Continue() == nil {
+			service.status.currentState = serviceRunning
+		}
+	case serviceControlInterrogate:
+	default:
+		return errorCallNotImplemented
+	}
+	setServiceStatus()
+	return 0
+}
+
+// setServiceStatus reports the current status, service.mu must be held
+func setServiceStatus() {
+	procSetServiceStatus.Call(service.handle, uintptr(unsafe.Pointer(&service.status)))
+}
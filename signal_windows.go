@@ -0,0 +1,69 @@
+package process
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+)
+
+const ctrlBreakEvent = 1
+
+var (
+	kernel32                     = syscall.NewLazyDLL("kernel32.dll")
+	procGenerateConsoleCtrlEvent = kernel32.NewProc("GenerateConsoleCtrlEvent")
+	procAttachConsole            = kernel32.NewProc("AttachConsole")
+	procFreeConsole              = kernel32.NewProc("FreeConsole")
+	procSetConsoleCtrlHandler    = kernel32.NewProc("SetConsoleCtrlHandler")
+
+	// consoleMu serializes attaching to child consoles, a process can have
+	// only one
+	consoleMu sync.Mutex
+)
+
+// setProcAttr starts the child in its own process group so that it can
+// receive CTRL_BREAK_EVENT without affecting the supervisor. Console Ctrl+C
+// doesn't reach such a child, so it's always detached.
+func setProcAttr(cmd *exec.Cmd, detach, pty bool) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+// interrupt sends CTRL_BREAK_EVENT to the child process group. When the
+// supervisor has no console shared with the child, it attaches to the child's
+// console for the time of sending. The child is terminated if neither works.
+func interrupt(proc *os.Process) error {
+	pid := uintptr(proc.Pid)
+	if r, _, _ := procGenerateConsoleCtrlEvent.Call(ctrlBreakEvent, pid); r != 0 {
+		return nil
+	}
+	if attachedCtrlBreak(pid) {
+		return nil
+	}
+	return proc.Kill()
+}
+
+func attachedCtrlBreak(pid uintptr) bool {
+	consoleMu.Lock()
+	defer consoleMu.Unlock()
+	procFreeConsole.Call()
+	if r, _, _ := procAttachConsole.Call(pid); r == 0 {
+		return false
+	}
+	defer procFreeConsole.Call()
+	// Ignore the event in the supervisor itself while attached
+	procSetConsoleCtrlHandler.Call(0, 1)
+	defer procSetConsoleCtrlHandler.Call(0, 0)
+	r, _, _ := procGenerateConsoleCtrlEvent.Call(ctrlBreakEvent, pid)
+	return r != 0
+}
+
+// parseSignal fails for every name, Windows children can't be signalled
+func parseSignal(name string) (os.Signal, error) {
+	return nil, fmt.Errorf("signal %q is not supported on this platform", name)
+}
+
+// killGroup does nothing as the descendants of the child are not tracked
+func killGroup(pid int) error {
+	return nil
+}
@@ -0,0 +1,14 @@
+package process
+
+import (
+	"io"
+)
+
+// Sink receives child output and lifecycle events
+type Sink interface {
+	// Writer returns destination for the output stream of a new start
+	// attempt, nil if the stream is not needed
+	Writer(stream Stream) io.Writer
+	// Event is called synchronously on every state change
+	Event(e Event)
+}
@@ -0,0 +1,281 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// Spec holds process configuration. It is never modified by the package, so
+// a single Spec can be run many times.
+type Spec struct {
+	Name              string      `json:"name"`              // Identifier in logs, events and the supervisor, base name of Cmd if empty
+	Cmd               string      `json:"cmd"`               // A path to executable to run
+	Args              []string    `json:"args"`              // Command-line argument list
+	CmdLookup         string      `json:"cmdLookup"`         // Resolution of Cmd without path separators, one of: "path" (default, search PATH), "dir" (in Dir), "none" (rejected)
+	RedactArgs        []string    `json:"redactArgs"`        // Name patterns of flags whose values are hidden in DisplayCommand, in addition to secret-looking ones
+	Dir               string      `json:"dir"`               // Process working directory
+	CreateDir         bool        `json:"createDir"`         // Create working directory if it does not exist
+	DirMode           os.FileMode `json:"dirMode"`           // Permissions for created directories, 0755 by default
+	Env               []string    `json:"env"`               // Inital environment
+	PassEnv           []string    `json:"passEnv"`           // Name patterns of parent variables passed to the child when Env is nil, all if empty
+	BlockEnv          []string    `json:"blockEnv"`          // Name patterns of parent variables never passed to the child
+	ExpandEnv         bool        `json:"expandEnv"`         // Replace ${VAR} in Cmd, Dir and Args with values from the child environment on every start
+	ArgFiles          bool        `json:"argFiles"`          // Replace "@file" arguments with lines of the file, relative to Dir, on every start; "@@" escapes a leading @
+	Stdout, Stderr    io.Writer   `json:"-"`                 // Standard IO pipes
+	StdinFile         string      `json:"stdinFile"`         // File opened as the child stdin on every start, relative to Dir
+	LogLevel          string      `json:"logLevel"`          // Messages of the package written to Stderr, one of: "error", "warn", "info" (default), "debug", "none"
+	CaptureOutput     int         `json:"captureOutput"`     // Size of combined output tail kept for RunResult, no capture if 0
+	StdoutLimit       int64       `json:"stdoutLimit"`       // Maximum stdout size per start attempt in bytes, unlimited if 0
+	StderrLimit       int64       `json:"stderrLimit"`       // Maximum stderr size per start attempt in bytes, unlimited if 0
+	OutputLimitAction string      `json:"outputLimitAction"` // One of: "truncate" (default), "rotate", "kill"
+	OutputBuffer      int         `json:"outputBuffer"`      // Size of the buffer in bytes between the child and slow Stdout and Stderr writers, writes are synchronous if 0
+	OutputOverflow    string      `json:"outputOverflow"`    // Action when OutputBuffer is full, one of: "block" (default), "drop", "kill"
+	StartTimeout      int         `json:"startTimeout"`      // Time to wait for process start in milliseconds
+	ReadyMode         string      `json:"readyMode"`         // One of: "timeout", "pattern", "probe", "notify"; "pattern" if ReadyPattern is set, "timeout" otherwise
+	ReadyPattern      string      `json:"readyPattern"`      // Regular expression on stdout signalling the start, StartTimeout becomes a deadline
+	BackoffTimeout    int         `json:"backoffTimeout"`    // Delay before another start attempt
+	StopTimeout       int         `json:"stopTimeout"`       // Time to wait for process stop in milliseconds
+	KillTimeout       int         `json:"killTimeout"`       // Time to wait after sending the kill signal in milliseconds
+	StopSequence      []StopStep  `json:"stopSequence"`      // Signals sent in order on stop instead of interrupt, the child is killed after the last step; Unix only
+	MaxStartAttempts  int         `json:"maxStartAttempts"`  // Maximum number of retries of consecutive failed launches allowed by RestartPolicy (default to give up first time)
+	MaxRestarts       int         `json:"maxRestarts"`       // Maximum number of restarts after completed runs (default to no restarts)
+	RestartTimeout    int         `json:"restartTimeout"`    // Delay before restart attempt
+	RestartPolicy     string      `json:"restartPolicy"`     // One of: "always", "on-failure", ""
+	Jitter            float64     `json:"jitter"`            // Fraction from 0 to 1 by which BackoffTimeout and RestartTimeout are randomly shortened or extended
+	SuccessExitCodes  []int       `json:"successExitCodes"`  // Nonzero exit codes treated as clean exits
+	Detach            bool        `json:"detach"`            // Start the child in a new session without controlling terminal
+	PTY               bool        `json:"pty"`               // Run the child on a pseudo-terminal in a new session, stdout and stderr are merged into Stdout; Linux only
+	PTYRecordDir      string      `json:"ptyRecordDir"`      // Directory for asciicast v2 recordings of PTY sessions, one file per run ID
+	Elevate           string      `json:"elevate"`           // Run the command through "sudo" or "doas" in non-interactive mode
+	CoreDump          bool        `json:"coreDump"`          // Raise the child core size limit to the hard limit (Linux only)
+	CrashDir          string      `json:"crashDir"`          // Directory for artifacts of crashed runs, one subdirectory per run ID
+	WatchChildren     int         `json:"watchChildren"`     // Interval of polling for new descendants reported to OnFork in milliseconds, Linux only
+	DrainSignal       string      `json:"drainSignal"`       // Signal sent on Drain, e.g. "SIGTERM", none if empty
+	DrainTimeout      int         `json:"drainTimeout"`      // Time to wait for in-flight work after Drain in milliseconds
+	Priority          int         `json:"priority"`          // Supervisor starts processes in ascending order of priority and stops in descending
+	Critical          bool        `json:"critical"`          // Supervisor is unhealthy unless the process is running and ready
+	WatchPaths        []string    `json:"watchPaths"`        // Glob patterns of files restarting the process gracefully on change
+	WatchDelay        int         `json:"watchDelay"`        // Polling interval and debounce delay for WatchPaths in milliseconds, 500 by default
+	Preflight         bool        `json:"preflight"`         // Check that executable and working directory exist before start
+	RequireEnv        []string    `json:"requireEnv"`        // Environment variables that must be set before start
+	RequirePorts      []string    `json:"requirePorts"`      // TCP addresses that must be free before start
+	Triggers          []Trigger   `json:"triggers"`          // Actions bound to child output patterns
+	Sink              Sink        `json:"-"`                 // Additional destination for output and events
+	LineFormat        *LineFormat `json:"lineFormat"`        // Prefix lines written to Stdout and Stderr, no formatting if nil
+
+	// StdoutPolicy and StderrPolicy override handling of a single stream,
+	// e.g. to discard stdout and keep stderr
+	StdoutPolicy *StreamPolicy `json:"stdoutPolicy,omitempty"`
+	StderrPolicy *StreamPolicy `json:"stderrPolicy,omitempty"`
+
+	// Enabled processes can be started by the supervisor, true if nil
+	Enabled *bool `json:"enabled,omitempty"`
+	// Autostart processes are started with the supervisor, others wait for
+	// Supervisor.Start; true if nil
+	Autostart *bool `json:"autostart,omitempty"`
+
+	// CPUThreshold raises alerts on CPU usage of the child, Linux only
+	CPUThreshold *CPUThreshold `json:"cpuThreshold"`
+	// FDThreshold raises alerts on open files of the child, Linux only
+	FDThreshold *FDThreshold `json:"fdThreshold"`
+	// Profiler periodically samples stacks or profiles of the child
+	Profiler *Profiler `json:"profiler"`
+
+	// StartLimiter delays starts exceeding the shared start rate
+	StartLimiter *StartLimiter `json:"-"`
+	// Metrics receives durations of lifecycle phases
+	Metrics Metrics `json:"-"`
+
+	// OnStateChange is called synchronously on every transition with the
+	// previous and the next state and the error that caused the transition
+	OnStateChange func(prev, next State, err error, at time.Time) `json:"-"`
+
+	// OnCrash is called after the child exited with error with the collected
+	// crash artifacts
+	OnCrash func(CrashInfo) `json:"-"`
+
+	// OnFork is called from a separate goroutine for every new descendant of
+	// the child found with WatchChildren polling
+	OnFork func(ProcInfo) `json:"-"`
+	// OnAlert is called from a separate goroutine when the child crosses
+	// resource usage threshold
+	OnAlert func(Alert) `json:"-"`
+	// OnSurvivors is called after the child exited with the processes it
+	// spawned that are still alive, Linux only
+	OnSurvivors func([]ProcInfo) `json:"-"`
+
+	// ReadyProbe is polled in "probe" ready mode until it reports that the
+	// child is ready, StartTimeout is the deadline
+	ReadyProbe func() bool `json:"-"`
+	// DrainProbe is polled during Drain and reports whether in-flight work
+	// is finished and the child can be stopped
+	DrainProbe func() bool `json:"-"`
+
+	// ArgsFunc returns command-line arguments for the start with the given
+	// number counted from 1. Overrides Args if set.
+	ArgsFunc func(attempt int) []string `json:"-"`
+	// StdinFunc returns the child stdin on every start, the reader is closed
+	// after the child exits if it is an io.Closer
+	StdinFunc func() io.Reader `json:"-"`
+	// EnvFunc returns variables added to the child environment for the start
+	// with the given number counted from 1
+	EnvFunc func(attempt int) []string `json:"-"`
+	// ContextEnv extracts values such as trace or request identifiers from
+	// the run context and returns them as variables added to the child
+	// environment on every start
+	ContextEnv func(ctx context.Context) []string `json:"-"`
+
+	inheritedEnv []string            // Env of the defaults added to the parent environment
+	setFields    map[string]struct{} // JSON names of fields present when the spec was decoded, never modified
+}
+
+// NewSpec creates process configuration with reasonable defaults
+func NewSpec(cmd string, args ...string) (res Spec) {
+	res.Cmd = cmd
+	res.Args = args
+	res.StartTimeout = startTimeout
+	res.StopTimeout = stopTimeout
+	res.BackoffTimeout = backoffTimeout
+	res.RestartTimeout = restartTimeout
+	res.KillTimeout = killTimeout
+	res.MaxStartAttempts = 10
+	res.MaxRestarts = -1
+	return
+}
+
+// Clone returns a deep copy of the spec. Output writers are shared between
+// the copies.
+func (s Spec) Clone() Spec {
+	s.Args = copyStrings(s.Args)
+	s.RedactArgs = copyStrings(s.RedactArgs)
+	s.Env = copyStrings(s.Env)
+	s.inheritedEnv = copyStrings(s.inheritedEnv)
+	s.PassEnv = copyStrings(s.PassEnv)
+	s.BlockEnv = copyStrings(s.BlockEnv)
+	s.WatchPaths = copyStrings(s.WatchPaths)
+	s.RequireEnv = copyStrings(s.RequireEnv)
+	s.RequirePorts = copyStrings(s.RequirePorts)
+	if s.StopSequence != nil {
+		s.StopSequence = append([]StopStep(nil), s.StopSequence...)
+	}
+	if s.SuccessExitCodes != nil {
+		s.SuccessExitCodes = append([]int(nil), s.SuccessExitCodes...)
+	}
+	if s.LineFormat != nil {
+		f := *s.LineFormat
+		s.LineFormat = &f
+	}
+	if s.StdoutPolicy != nil {
+		v := *s.StdoutPolicy
+		s.StdoutPolicy = &v
+	}
+	if s.StderrPolicy != nil {
+		v := *s.StderrPolicy
+		s.StderrPolicy = &v
+	}
+	if s.Enabled != nil {
+		v := *s.Enabled
+		s.Enabled = &v
+	}
+	if s.Autostart != nil {
+		v := *s.Autostart
+		s.Autostart = &v
+	}
+	if s.CPUThreshold != nil {
+		t := *s.CPUThreshold
+		s.CPUThreshold = &t
+	}
+	if s.FDThreshold != nil {
+		t := *s.FDThreshold
+		s.FDThreshold = &t
+	}
+	if s.Profiler != nil {
+		pr := *s.Profiler
+		pr.Args = copyStrings(pr.Args)
+		s.Profiler = &pr
+	}
+	if s.Triggers != nil {
+		s.Triggers = append([]Trigger(nil), s.Triggers...)
+	}
+	return s
+}
+
+// Run starts a new process from the spec and returns its handle
+func (s Spec) Run(ctx context.Context) (res *Handle) {
+	res = NewHandle(s)
+	res.run(ctx)
+	return
+}
+
+// procName returns Name or the base name of Cmd
+func (s Spec) procName() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return filepath.Base(s.Cmd)
+}
+
+func (s Spec) enabled() bool {
+	return s.Enabled == nil || *s.Enabled
+}
+
+func (s Spec) autostart() bool {
+	return s.Autostart == nil || *s.Autostart
+}
+
+// exitError wraps the child exit error into ExitError and drops it if the
+// exit code is in SuccessExitCodes
+func (s Spec) exitError(err error) error {
+	if errors.Is(err, exec.ErrWaitDelay) {
+		// The child exited successfully, its descendants held the output
+		return nil
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return err
+	}
+	for _, code := range s.SuccessExitCodes {
+		if exitErr.ExitCode() == code {
+			return nil
+		}
+	}
+	return &ExitError{Err: exitErr}
+}
+
+// waitDelay limits the time to wait for output pipes held by descendants
+// after the child exited
+func (s Spec) waitDelay() time.Duration {
+	if s.KillTimeout <= 0 {
+		return killTimeout * time.Millisecond
+	}
+	return time.Duration(s.KillTimeout) * time.Millisecond
+}
+
+// jittered randomizes the delay in milliseconds by up to Jitter of it in
+// either direction, so that processes restarted together spread out
+func (s Spec) jittered(delay int) int {
+	if s.Jitter == 0 || delay == 0 {
+		return delay
+	}
+	return int(float64(delay) * (1 + s.Jitter*(2*rand.Float64()-1)))
+}
+
+func (s Spec) dirMode() os.FileMode {
+	if s.DirMode == 0 {
+		return 0755
+	}
+	return s.DirMode
+}
+
+func copyStrings(src []string) []string {
+	if src == nil {
+		return nil
+	}
+	return append(make([]string, 0, len(src)), src...)
+}
@@ -0,0 +1,57 @@
+package process_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/andviro/process"
+)
+
+func TestClone(t *testing.T) {
+	tpl := process.New("/bin/sh", "-c", "exit 0")
+	tpl.Env = []string{"A=1"}
+	<-tpl.Run(context.TODO())
+
+	p := tpl.Clone()
+	p.Args[1] = "exit 1"
+	p.Env = append(p.Env[:0], "A=2")
+	if tpl.Args[1] != "exit 0" || tpl.Env[0] != "A=1" {
+		t.Errorf("template modified: %v %v", tpl.Args, tpl.Env)
+	}
+	if p.State != process.StateIdle || p.StartAttempt != 0 {
+		t.Errorf("run-time state copied: %+v", p.Status())
+	}
+	if p.StartTimeout != tpl.StartTimeout {
+		t.Errorf("configuration not copied: %d", p.StartTimeout)
+	}
+	if res := <-p.Run(context.TODO()); res.ExitCode != 1 {
+		t.Errorf("invalid exit code: %d", res.ExitCode)
+	}
+}
+
+func TestCreateDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "a", "b")
+	p := &process.Process{Spec: process.Spec{
+		Cmd:       "/bin/sh",
+		Args:      []string{"-c", "touch marker"},
+		Dir:       dir,
+		CreateDir: true,
+		DirMode:   0700,
+		Preflight: true,
+	}}
+	if res := <-p.Run(context.TODO()); res.Err != nil {
+		t.Fatalf("%+v", res.Err)
+	}
+	fi, err := os.Stat(dir)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if fi.Mode().Perm() != 0700 {
+		t.Errorf("invalid mode: %v", fi.Mode())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "marker")); err != nil {
+		t.Errorf("%+v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package process
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// StartLimiter is a token bucket limiting the rate of process starts. A
+// single limiter is shared between processes, e.g. through
+// Supervisor.Defaults, to keep crash-looping children from flooding the host
+// with fork/exec.
+type StartLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // Tokens per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewStartLimiter creates a limiter allowing rate starts per second on
+// average and up to burst starts at once. It panics if rate is not positive.
+func NewStartLimiter(rate float64, burst int) *StartLimiter {
+	if !(rate > 0) || math.IsInf(rate, 1) {
+		panic("process: start rate must be positive and finite")
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &StartLimiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// Wait blocks until a start is allowed or the context is done
+func (l *StartLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	l.tokens--
+	deficit := -l.tokens
+	l.mu.Unlock()
+	if deficit <= 0 {
+		return nil
+	}
+	delay := time.Duration(math.MaxInt64)
+	if d := deficit / l.rate * float64(time.Second); d < float64(delay) {
+		delay = time.Duration(d)
+	}
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,65 @@
+package process_test
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/andviro/process"
+)
+
+func TestStartLimiter(t *testing.T) {
+	l := process.NewStartLimiter(20, 2)
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := l.Wait(context.TODO()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond || elapsed > 200*time.Millisecond {
+		t.Errorf("invalid delay: %v", elapsed)
+	}
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); err == nil {
+		t.Error("expected context error")
+	}
+}
+
+func TestSupervisorStartRate(t *testing.T) {
+	s := process.Supervisor{Defaults: process.Spec{StartLimiter: process.NewStartLimiter(10, 1)}}
+	s.Add("crashing", &process.Process{Spec: process.Spec{
+		Cmd:              "/bin/false",
+		StartTimeout:     1000,
+		RestartPolicy:    "on-failure",
+		BackoffTimeout:   1,
+		MaxStartAttempts: -1,
+	}})
+	ctx, cancel := context.WithTimeout(context.TODO(), 350*time.Millisecond)
+	defer cancel()
+	res := s.Run(ctx)["crashing"]
+	if res.Attempts < 3 || res.Attempts > 5 {
+		t.Errorf("invalid number of starts: %d", res.Attempts)
+	}
+}
+
+func TestStartLimiterRate(t *testing.T) {
+	for _, rate := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("rate %v accepted", rate)
+				}
+			}()
+			process.NewStartLimiter(rate, 1)
+		}()
+	}
+	l := process.NewStartLimiter(1e-12, 1)
+	l.Wait(context.TODO())
+	ctx, cancel := context.WithTimeout(context.TODO(), 50*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("tiny rate not limited: %v", err)
+	}
+}
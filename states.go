@@ -0,0 +1,93 @@
+package process
+
+import (
+	"fmt"
+)
+
+// State is a process lifecycle state
+type State int
+
+// Process states
+const (
+	StateIdle            State = iota // Process has not been run yet
+	StateStarting                     // Process launched and waiting for StartTimeout
+	StateRunning                      // Process started successfully
+	StateBackoff                      // Waiting before another start attempt
+	StateRestarting                   // Waiting before restart
+	StateStopping                     // Stop signal sent, waiting for exit
+	StateKilling                      // Kill signal sent, waiting for exit
+	StateStopped                      // Process finished
+	StateFailed                       // Process failed and will not be restarted
+	StatePreflightFailed              // Pre-flight checks did not pass
+	StateDraining                     // Drain signal sent, waiting for in-flight work to finish
+)
+
+var stateNames = [...]string{
+	StateIdle:            "idle",
+	StateStarting:        "starting",
+	StateRunning:         "running",
+	StateBackoff:         "backoff",
+	StateRestarting:      "restarting",
+	StateStopping:        "stopping",
+	StateKilling:         "killing",
+	StateStopped:         "stopped",
+	StateFailed:          "failed",
+	StatePreflightFailed: "preflight-failed",
+	StateDraining:        "draining",
+}
+
+// states maps state function names to states
+var states = map[string]State{
+	"starting":        StateStarting,
+	"running":         StateRunning,
+	"backoff":         StateBackoff,
+	"restarting":      StateRestarting,
+	"stopping":        StateStopping,
+	"killing":         StateKilling,
+	"stopped":         StateStopped,
+	"failed":          StateFailed,
+	"preflightFailed": StatePreflightFailed,
+	"draining":        StateDraining,
+}
+
+// up reports whether the child is serving in the state
+func (s State) up() bool {
+	return s == StateRunning || s == StateDraining
+}
+
+// terminal reports whether the state is final
+func (s State) terminal() bool {
+	switch s {
+	case StateStopped, StateFailed, StatePreflightFailed:
+		return true
+	}
+	return false
+}
+
+func (s State) String() string {
+	if s < 0 || int(s) >= len(stateNames) {
+		return fmt.Sprintf("State(%d)", int(s))
+	}
+	return stateNames[s]
+}
+
+// ParseState converts state name to State
+func ParseState(name string) (State, error) {
+	for s, n := range stateNames {
+		if n == name {
+			return State(s), nil
+		}
+	}
+	return StateIdle, fmt.Errorf("unknown process state %q", name)
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (s State) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (s *State) UnmarshalText(text []byte) (err error) {
+	*s, err = ParseState(string(text))
+	return
+}
@@ -0,0 +1,35 @@
+package process_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/andviro/process"
+)
+
+func TestStateJSON(t *testing.T) {
+	src := struct {
+		State process.State `json:"state"`
+	}{process.StatePreflightFailed}
+	data, err := json.Marshal(src)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if string(data) != `{"state":"preflight-failed"}` {
+		t.Errorf("invalid JSON: %s", data)
+	}
+	dst := src
+	dst.State = process.StateIdle
+	if err := json.Unmarshal(data, &dst); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if dst != src {
+		t.Errorf("invalid state: %v", dst.State)
+	}
+	if err := json.Unmarshal([]byte(`{"state":"runnning"}`), &dst); err == nil {
+		t.Error("expected error on unknown state")
+	}
+	if s := process.State(100).String(); s != "State(100)" {
+		t.Errorf("invalid string: %s", s)
+	}
+}
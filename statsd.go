@@ -0,0 +1,95 @@
+package process
+
+import (
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StatsD sends process metrics to a StatsD server over UDP. It is both Sink
+// and Metrics: restarts and failures are counters, states are gauges set to
+// 1 for the current state and 0 for the left one, and phase durations,
+// including the start latency, are timings. Metric names are
+// prefix.name.metric, or prefix.metric tagged with process:name in Datadog
+// mode.
+type StatsD struct {
+	// Datadog enables DogStatsD tags instead of process names in metric names
+	Datadog bool
+
+	conn   net.Conn
+	prefix string
+}
+
+// NewStatsD connects to the server at the UDP address. Prefix is prepended
+// to metric names if not empty.
+func NewStatsD(addr, prefix string) (*StatsD, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if prefix != "" {
+		prefix = statsdName(prefix) + "."
+	}
+	return &StatsD{conn: conn, prefix: prefix}, nil
+}
+
+// Close closes the connection
+func (s *StatsD) Close() error {
+	return s.conn.Close()
+}
+
+// Writer returns nil, output is not sent
+func (s *StatsD) Writer(stream Stream) io.Writer {
+	return nil
+}
+
+// Event updates the counters and state gauges
+func (s *StatsD) Event(e Event) {
+	switch e.State {
+	case StateRestarting:
+		s.send(e.Name, "restarts", "1|c")
+	case StateFailed, StatePreflightFailed:
+		s.send(e.Name, "failures", "1|c")
+	}
+	if e.Prev != StateIdle {
+		s.send(e.Name, "state."+e.Prev.String(), "0|g")
+	}
+	s.send(e.Name, "state."+e.State.String(), "1|g")
+}
+
+// ObservePhase sends the phase duration as timing in milliseconds
+func (s *StatsD) ObservePhase(name string, phase Phase, d time.Duration) {
+	s.send(name, string(phase), strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', -1, 64)+"|ms")
+}
+
+// send writes a single metric, errors are ignored as UDP delivery is not
+// guaranteed anyway
+func (s *StatsD) send(name, metric, value string) {
+	var b strings.Builder
+	b.WriteString(s.prefix)
+	if !s.Datadog {
+		b.WriteString(statsdName(name))
+		b.WriteByte('.')
+	}
+	b.WriteString(metric)
+	b.WriteByte(':')
+	b.WriteString(value)
+	if s.Datadog {
+		b.WriteString("|#process:")
+		b.WriteString(statsdName(name))
+	}
+	s.conn.Write([]byte(b.String()))
+}
+
+// statsdName replaces characters having special meaning in the protocol
+func statsdName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ':', '|', '@', '#', ',', ' ', '\n':
+			return '_'
+		}
+		return r
+	}, name)
+}
@@ -0,0 +1,59 @@
+package process_test
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andviro/process"
+)
+
+func TestStatsD(t *testing.T) {
+	for _, datadog := range []bool{false, true} {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		statsd, err := process.NewStatsD(conn.LocalAddr().String(), "sv")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer statsd.Close()
+		statsd.Datadog = datadog
+		p := &process.Process{Spec: process.Spec{
+			Name:           "app",
+			Cmd:            "/bin/true",
+			StartTimeout:   1000,
+			RestartPolicy:  "always",
+			MaxRestarts:    1,
+			RestartTimeout: 10,
+			Sink:           statsd,
+			Metrics:        statsd,
+		}}
+		<-p.Run(context.TODO())
+
+		var packets []string
+		buf := make([]byte, 512)
+		for {
+			conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				break
+			}
+			packets = append(packets, string(buf[:n]))
+		}
+		all := strings.Join(packets, "\n")
+		expected := []string{"sv.app.restarts:1|c", "sv.app.state.starting:1|g", "sv.app.state.starting:0|g", "sv.app.start:"}
+		if datadog {
+			expected = []string{"sv.restarts:1|c|#process:app", "sv.state.stopped:1|g|#process:app"}
+		}
+		for _, s := range expected {
+			if !strings.Contains(all, s) {
+				t.Errorf("datadog %v: %q not found in:\n%s", datadog, s, all)
+			}
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package process
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// openStdin returns the child stdin for the start attempt: the pipe of
+// Pipeline, StdinFile opened relative to dir or the reader of StdinFunc
+func (p *Process) openStdin(dir string) (io.Reader, error) {
+	switch {
+	case p.stdin != nil:
+		return p.stdin, nil
+	case p.StdinFile != "":
+		name := p.StdinFile
+		if !filepath.IsAbs(name) && dir != "" {
+			name = filepath.Join(dir, name)
+		}
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		return f, nil
+	case p.StdinFunc != nil:
+		return p.StdinFunc(), nil
+	}
+	return nil, nil
+}
+
+// closeStdin closes the reader returned by openStdin, the Pipeline closes
+// its pipes itself
+func (p *Process) closeStdin(r io.Reader) {
+	if c, ok := r.(io.Closer); ok && p.stdin == nil {
+		c.Close()
+	}
+}
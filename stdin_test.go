@@ -0,0 +1,70 @@
+package process_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/andviro/process"
+)
+
+type closingReader struct {
+	io.Reader
+	closed *int
+}
+
+func (r closingReader) Close() error {
+	*r.closed++
+	return nil
+}
+
+func TestStdinFunc(t *testing.T) {
+	var stdout syncBuffer
+	starts, closed := 0, 0
+	p := &process.Process{Spec: process.Spec{
+		Cmd:           "/bin/cat",
+		Stdout:        &stdout,
+		RestartPolicy: "always",
+		MaxRestarts:   2,
+		StdinFunc: func() io.Reader {
+			starts++
+			return closingReader{strings.NewReader("run " + strconv.Itoa(starts) + "\n"), &closed}
+		},
+	}}
+	<-p.Run(context.TODO())
+	if out := stdout.String(); out != "run 1\nrun 2\nrun 3\n" {
+		t.Errorf("invalid output: %q", out)
+	}
+	if closed != 3 {
+		t.Errorf("readers closed %d times", closed)
+	}
+}
+
+func TestStdinFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "input"), []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout syncBuffer
+	p := &process.Process{Spec: process.Spec{Cmd: "/bin/cat", Dir: dir, StdinFile: "input", Stdout: &stdout}}
+	if res := <-p.Run(context.TODO()); res.Err != nil {
+		t.Fatal(res.Err)
+	}
+	if out := stdout.String(); out != "hello\n" {
+		t.Errorf("invalid output: %q", out)
+	}
+
+	p = &process.Process{Spec: process.Spec{Cmd: "/bin/cat", Dir: dir, StdinFile: "missing"}}
+	if res := <-p.Run(context.TODO()); !errors.Is(res.Err, os.ErrNotExist) {
+		t.Errorf("invalid error: %v", res.Err)
+	}
+	spec := process.Spec{Cmd: "/bin/cat", StdinFile: "input", StdinFunc: func() io.Reader { return nil }}
+	if err := spec.Validate(); err == nil {
+		t.Error("conflicting stdin accepted")
+	}
+}
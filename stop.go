@@ -0,0 +1,60 @@
+package process
+
+import (
+	"gopkg.in/andviro/go-state.v2"
+
+	"context"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// StopStep is a single step of the stop escalation chain
+type StopStep struct {
+	Signal string `json:"signal"` // Signal sent to the child, e.g. "SIGTERM"
+	Wait   int    `json:"wait"`   // Time to wait for the child to exit in milliseconds
+}
+
+func (s StopStep) validate() error {
+	if _, err := parseSignal(s.Signal); err != nil {
+		return err
+	}
+	if s.Wait < 0 {
+		return errors.New("wait: must not be negative")
+	}
+	return nil
+}
+
+// escalate sends signals of StopSequence one after another until the child
+// exits. The child is killed if it survived the whole sequence. Exits after
+// any step but SIGKILL are clean, the later steps may be the intended
+// graceful ones.
+func (p *Process) escalate(c context.Context) (res state.Func) {
+	for _, step := range p.StopSequence {
+		sig, err := parseSignal(step.Signal)
+		if err == nil {
+			err = p.cmd.Process.Signal(sig)
+		}
+		if err != nil && !errors.Is(err, os.ErrProcessDone) {
+			p.LastError = fmt.Errorf("stop sequence: %w", err)
+			return p.failed
+		}
+		select {
+		case err := <-p.result:
+			var exitErr *ExitError
+			switch {
+			case sig == os.Kill:
+				p.LastError = wrap(ErrStopTimeout, err)
+			case errors.As(err, &exitErr):
+				p.LastError = nil
+			default:
+				p.LastError = err
+			}
+			p.exited(nil)
+			return p.afterStop(c)
+		case <-p.after(step.Wait):
+			p.debugf("process survived %s", step.Signal)
+		}
+	}
+	return p.killing
+}
@@ -0,0 +1,105 @@
+package process_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/andviro/process"
+)
+
+func TestStopSequence(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		steps []process.StopStep
+		err   error
+	}{
+		{"first", []process.StopStep{{"SIGUSR1", 1000}}, nil},
+		{"escalated", []process.StopStep{{"SIGINT", 100}, {"SIGUSR1", 1000}}, nil},
+		{"sigkill", []process.StopStep{{"SIGINT", 100}, {"SIGKILL", 1000}}, process.ErrStopTimeout},
+		{"killed", []process.StopStep{{"SIGINT", 100}, {"SIGHUP", 100}}, process.ErrStopTimeout},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			spec := process.Spec{
+				Cmd:          "/bin/sh",
+				Args:         []string{"-c", "trap '' INT HUP; trap 'exit 0' USR1; while :; do sleep 0.01; done"},
+				StartTimeout: 50,
+				KillTimeout:  1000,
+				StopSequence: tc.steps,
+			}
+			if err := spec.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			h := spec.Run(context.TODO())
+			var states []process.State
+			for e := range h.Events() {
+				states = append(states, e.State)
+				if e.State == process.StateRunning {
+					h.Stop()
+				}
+			}
+			res := h.Wait()
+			if tc.err == nil && res.Err != nil || !errors.Is(res.Err, tc.err) || res.State != process.StateStopped {
+				t.Errorf("invalid result: %+v", res)
+			}
+			killed := states[len(states)-2] == process.StateKilling
+			if killed != (tc.name == "killed") {
+				t.Errorf("invalid states: %v", states)
+			}
+		})
+	}
+}
+
+func TestStopSequenceValidate(t *testing.T) {
+	s := process.Spec{Cmd: "/bin/true", StopSequence: []process.StopStep{{"SIGFOO", 0}, {"SIGTERM", -1}}}
+	var verr *process.ValidationError
+	if !errors.As(s.Validate(), &verr) || len(verr.Errors) != 2 {
+		t.Errorf("%v", s.Validate())
+	}
+}
+
+func TestKillGroup(t *testing.T) {
+	for _, detach := range []bool{true, false} {
+		var stdout syncBuffer
+		spec := process.Spec{
+			Cmd:          "/bin/sh",
+			Args:         []string{"-c", "trap '' INT; sleep 5 & wait"},
+			Stdout:       &stdout,
+			StartTimeout: 50,
+			StopTimeout:  50,
+			KillTimeout:  200,
+			Detach:       detach,
+		}
+		start := time.Now()
+		h := spec.Run(context.TODO())
+		for e := range h.Events() {
+			if e.State == process.StateRunning {
+				h.Stop()
+			}
+		}
+		res := h.Wait()
+		if !errors.Is(res.Err, process.ErrStopTimeout) || res.State != process.StateStopped {
+			t.Errorf("child with held pipes not stopped: %+v", res)
+		}
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("stop took %v", elapsed)
+		}
+	}
+}
+
+func TestHeldPipesCleanExit(t *testing.T) {
+	var stdout syncBuffer
+	spec := process.Spec{
+		Cmd:          "/bin/sh",
+		Args:         []string{"-c", "sleep 0.1; sleep 3 & exit 0"},
+		Stdout:       &stdout,
+		StartTimeout: 10,
+		KillTimeout:  100,
+	}
+	start := time.Now()
+	res := spec.Run(context.TODO()).Wait()
+	if res.Err != nil || res.State != process.StateStopped || time.Since(start) > time.Second {
+		t.Errorf("invalid result: %+v", res)
+	}
+}
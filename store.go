@@ -0,0 +1,192 @@
+package process
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrNoRecord is reported when the store has no record with requested name
+var ErrNoRecord = errors.New("no such record")
+
+// Record is the persistent part of process state
+type Record struct {
+	Name         string        `json:"name"`         // Unique process name within the store
+	Spec         Spec          `json:"spec"`         // Process configuration
+	State        State         `json:"state"`        // Last known state
+	Pid          int           `json:"pid"`          // Pid of the running child, 0 if there's none
+	RunID        string        `json:"runId"`        // Identifier of the last start attempt
+	RestartCount int           `json:"restartCount"` // Number of restarts
+	Uptime       time.Duration `json:"uptime"`       // Total time spent running
+	Downtime     time.Duration `json:"downtime"`     // Total time spent starting, restarting or stopping
+	CleanExits   int           `json:"cleanExits"`   // Number of successful or requested exits
+	CrashExits   int           `json:"crashExits"`   // Number of exits with error
+	UpdatedAt    time.Time     `json:"updatedAt"`    // Time when the record was saved
+
+	// Child handed over with Supervisor.Reexec, nil otherwise
+	Handoff *Handoff `json:"handoff,omitempty"`
+}
+
+// Store persists process records between supervisor runs
+type Store interface {
+	Load(name string) (Record, error)
+	Save(r Record) error
+	Delete(name string) error
+	List() ([]Record, error)
+}
+
+// Record returns the current state of the process as a record with the name
+func (p *Process) Record(name string) Record {
+	st := p.Status()
+	return Record{
+		Name:         name,
+		Spec:         p.Spec,
+		State:        st.State,
+		Pid:          st.Pid,
+		RunID:        st.RunID,
+		RestartCount: st.RestartCount,
+		Uptime:       st.Uptime,
+		Downtime:     st.Downtime,
+		CleanExits:   st.CleanExits,
+		CrashExits:   st.CrashExits,
+		UpdatedAt:    time.Now(),
+	}
+}
+
+// Restore loads counters from the record into a process that is not running.
+// The configuration is not changed.
+func (p *Process) Restore(r Record) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.active {
+		return ErrAlreadyRunning
+	}
+	p.RestartCount = r.RestartCount
+	p.uptime, p.downtime = r.Uptime, r.Downtime
+	p.cleanExits, p.crashExits = r.CleanExits, r.CrashExits
+	p.status.RestartCount = r.RestartCount
+	p.status.Uptime, p.status.Downtime = r.Uptime, r.Downtime
+	p.status.CleanExits, p.status.CrashExits = r.CleanExits, r.CrashExits
+	return nil
+}
+
+// Persist saves the process record to the store on every state change until
+// the end of the next run. Save errors are passed to onError if it's not nil.
+func (p *Process) Persist(s Store, name string, onError func(error)) (cancel func()) {
+	events, cancel := p.Subscribe(nil)
+	go func() {
+		for range events {
+			if err := s.Save(p.Record(name)); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}()
+	return
+}
+
+// FileStore keeps records in a single JSON file, replacing it atomically on
+// every change
+type FileStore struct {
+	path    string
+	mu      sync.Mutex
+	records map[string]Record
+}
+
+// OpenFileStore reads the records from the file. Missing file is treated as
+// empty store.
+func OpenFileStore(path string) (*FileStore, error) {
+	s := &FileStore{path: path, records: make(map[string]Record)}
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return s, nil
+	case err != nil:
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.records); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Load returns the record with the name
+func (s *FileStore) Load(name string) (Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.records[name]
+	if !ok {
+		return Record{}, ErrNoRecord
+	}
+	return r, nil
+}
+
+// Save stores the record replacing existing one with the same name
+func (s *FileStore) Save(r Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev, ok := s.records[r.Name]
+	s.records[r.Name] = r
+	if err := s.flush(); err != nil {
+		if ok {
+			s.records[r.Name] = prev
+		} else {
+			delete(s.records, r.Name)
+		}
+		return err
+	}
+	return nil
+}
+
+// Delete removes the record with the name
+func (s *FileStore) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev, ok := s.records[name]
+	if !ok {
+		return ErrNoRecord
+	}
+	delete(s.records, name)
+	if err := s.flush(); err != nil {
+		s.records[name] = prev
+		return err
+	}
+	return nil
+}
+
+// List returns all records ordered by name
+func (s *FileStore) List() ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := make([]Record, 0, len(s.records))
+	for _, r := range s.records {
+		res = append(res, r)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res, nil
+}
+
+func (s *FileStore) flush() error {
+	data, err := json.MarshalIndent(s.records, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(data); err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
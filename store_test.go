@@ -0,0 +1,71 @@
+package process_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/andviro/process"
+)
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	s, err := process.OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &process.Process{Spec: process.Spec{
+		Cmd:            "/bin/sh",
+		Args:           []string{"-c", "sleep 0.1; exit 1"},
+		StartTimeout:   50,
+		RestartPolicy:  "on-failure",
+		MaxRestarts:    1,
+		RestartTimeout: 10,
+	}}
+	errs := make(chan error, 10)
+	p.Persist(s, "worker", func(err error) { errs <- err })
+	<-p.Run(context.TODO())
+	for i := 0; i < 100; i++ {
+		if r, _ := s.Load("worker"); r.State == process.StateFailed {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	select {
+	case err := <-errs:
+		t.Fatal(err)
+	default:
+	}
+
+	s, err = process.OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := s.Load("worker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.State != process.StateFailed || r.RestartCount != 2 || r.CrashExits != 2 || r.Spec.Cmd != "/bin/sh" {
+		t.Errorf("invalid record: %+v", r)
+	}
+
+	restored := &process.Process{Spec: r.Spec}
+	if err := restored.Restore(r); err != nil {
+		t.Fatal(err)
+	}
+	if st := restored.Status(); st.RestartCount != 2 || st.CrashExits != 2 || st.Uptime != r.Uptime {
+		t.Errorf("invalid restored status: %+v", st)
+	}
+
+	if err := s.Delete("worker"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Load("worker"); !errors.Is(err, process.ErrNoRecord) {
+		t.Errorf("%+v", err)
+	}
+	if list, _ := s.List(); len(list) != 0 {
+		t.Errorf("invalid records: %+v", list)
+	}
+}
@@ -0,0 +1,324 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Supervisor runs a set of named processes together. The zero value is ready
+// to use.
+type Supervisor struct {
+	// Defaults are applied to specs of processes when they are added, see
+	// Spec.WithDefaults
+	Defaults Spec
+	// Audit records operations requested with Start, Stop, Apply and Drain,
+	// nothing is recorded if nil
+	Audit *AuditLog
+
+	mu    sync.RWMutex
+	names []string
+	procs map[string]*Process
+	run   *supervisorRun
+}
+
+// supervisorRun holds processes started during a single Run
+type supervisorRun struct {
+	ctx     context.Context
+	mu      sync.Mutex
+	members []*supervised
+	stopped bool
+	applied int           // Number of Apply calls in progress
+	changed chan struct{} // Signalled when a process is started or finished
+}
+
+// Add registers the process under the unique name and applies Defaults to
+// its spec. The process Name, or the base name of Cmd, is used if the name is
+// empty; otherwise Name is set to the name.
+func (s *Supervisor) Add(name string, p *Process) error {
+	if name == "" {
+		name = p.procName()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.procs == nil {
+		s.procs = make(map[string]*Process)
+	}
+	if _, ok := s.procs[name]; ok {
+		return fmt.Errorf("%w: %q", ErrDuplicateName, name)
+	}
+	p.Spec = p.Spec.WithDefaults(s.Defaults)
+	p.Name = name
+	s.procs[name] = p
+	s.names = append(s.names, name)
+	return nil
+}
+
+// Get returns the named process or nil
+func (s *Supervisor) Get(name string) *Process {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.procs[name]
+}
+
+// Names returns names of the processes in order of addition
+func (s *Supervisor) Names() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]string(nil), s.names...)
+}
+
+// Run starts enabled processes with autostart in ascending order of
+// Priority, each priority group after the previous one is up. Processes
+// without autostart can be started later with Start. Run waits until every
+// started process finishes, or, if there are processes left to Start, until
+// the context is cancelled. Cancelling the context stops the processes in
+// descending order of Priority, groups that were not started yet are
+// skipped. The result holds the last run of every started process.
+func (s *Supervisor) Run(ctx context.Context) map[string]RunResult {
+	run := &supervisorRun{
+		// Processes are stopped in order, so they must not see the
+		// cancellation of ctx itself
+		ctx:     context.WithoutCancel(ctx),
+		changed: make(chan struct{}, 1),
+	}
+	s.mu.Lock()
+	s.run = run
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.run = nil
+		s.mu.Unlock()
+	}()
+
+	var (
+		members []*supervised
+		manual  bool
+	)
+	for _, name := range s.Names() {
+		p := s.Get(name)
+		switch {
+		case !p.enabled():
+		case !p.autostart() && !p.handedOver():
+			manual = true
+		default:
+			members = append(members, &supervised{name: name, p: p})
+		}
+	}
+	for _, g := range byPriority(members) {
+		if ctx.Err() != nil {
+			break
+		}
+		for _, m := range g {
+			run.start(m)
+		}
+		for _, m := range g {
+			select {
+			case <-m.up:
+			case <-m.done:
+			case <-ctx.Done():
+			}
+		}
+	}
+
+	for !run.finished() || manual {
+		select {
+		case <-run.changed:
+		case <-ctx.Done():
+			run.stop()
+			return run.results()
+		}
+	}
+	return run.results()
+}
+
+// Start starts the named process while the supervisor is running
+func (s *Supervisor) Start(name string) error {
+	return s.audit("", OpStart, name, s.start(name))
+}
+
+func (s *Supervisor) start(name string) error {
+	s.mu.RLock()
+	p, run := s.procs[name], s.run
+	s.mu.RUnlock()
+	switch {
+	case p == nil:
+		return fmt.Errorf("%w: %q", ErrNoProcess, name)
+	case !p.enabled():
+		return fmt.Errorf("%w: %q", ErrDisabled, name)
+	case run == nil:
+		return ErrNotRunning
+	}
+	p.mu.RLock()
+	active := p.active
+	p.mu.RUnlock()
+	if active {
+		return ErrAlreadyRunning
+	}
+	if !run.start(&supervised{name: name, p: p}) {
+		return ErrNotRunning
+	}
+	return nil
+}
+
+// Stop stops the named process started by the running supervisor
+func (s *Supervisor) Stop(name string) error {
+	return s.audit("", OpStop, name, s.stop(name))
+}
+
+func (s *Supervisor) stop(name string) error {
+	s.mu.RLock()
+	p, run := s.procs[name], s.run
+	s.mu.RUnlock()
+	switch {
+	case p == nil:
+		return fmt.Errorf("%w: %q", ErrNoProcess, name)
+	case run == nil:
+		return ErrNotRunning
+	}
+	m := run.last(name)
+	if m == nil {
+		return ErrNotRunning
+	}
+	m.cancel()
+	return nil
+}
+
+// start runs the process unless the supervisor is stopping
+func (r *supervisorRun) start(m *supervised) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopped {
+		return false
+	}
+	r.members = append(r.members, m)
+	m.start(r.ctx, r.notify)
+	r.notify()
+	return true
+}
+
+func (r *supervisorRun) notify() {
+	select {
+	case r.changed <- struct{}{}:
+	default:
+	}
+}
+
+func (r *supervisorRun) snapshot() []*supervised {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*supervised(nil), r.members...)
+}
+
+// last returns the latest run of the named process
+func (r *supervisorRun) last(name string) *supervised {
+	members := r.snapshot()
+	for i := len(members) - 1; i >= 0; i-- {
+		if members[i].name == name {
+			return members[i]
+		}
+	}
+	return nil
+}
+
+// hold keeps the run from finishing until release is called, while
+// processes are replaced
+func (r *supervisorRun) hold() (release func()) {
+	r.mu.Lock()
+	r.applied++
+	r.mu.Unlock()
+	return func() {
+		r.mu.Lock()
+		r.applied--
+		r.mu.Unlock()
+		r.notify()
+	}
+}
+
+// finished reports whether all started processes have finished
+func (r *supervisorRun) finished() bool {
+	r.mu.Lock()
+	applied := r.applied
+	r.mu.Unlock()
+	if applied != 0 {
+		return false
+	}
+	for _, m := range r.snapshot() {
+		select {
+		case <-m.done:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// stop stops processes in descending order of priority
+func (r *supervisorRun) stop() {
+	r.mu.Lock()
+	r.stopped = true
+	r.mu.Unlock()
+	groups := byPriority(r.snapshot())
+	for i := len(groups) - 1; i >= 0; i-- {
+		for _, m := range groups[i] {
+			m.cancel()
+		}
+		for _, m := range groups[i] {
+			<-m.done
+		}
+	}
+}
+
+func (r *supervisorRun) results() map[string]RunResult {
+	res := make(map[string]RunResult)
+	for _, m := range r.snapshot() {
+		<-m.done
+		res[m.name] = m.result
+	}
+	return res
+}
+
+// byPriority groups processes by ascending priority keeping the order within
+// a group
+func byPriority(members []*supervised) (res [][]*supervised) {
+	members = append([]*supervised(nil), members...)
+	sort.SliceStable(members, func(i, j int) bool { return members[i].p.Priority < members[j].p.Priority })
+	for i, m := range members {
+		if i == 0 || m.p.Priority != members[i-1].p.Priority {
+			res = append(res, nil)
+		}
+		res[len(res)-1] = append(res[len(res)-1], m)
+	}
+	return
+}
+
+// supervised tracks a single run of a supervised process
+type supervised struct {
+	name   string
+	p      *Process
+	cancel context.CancelFunc
+	up     chan struct{} // Closed when the process is up or has finished
+	done   chan struct{} // Closed when the run result is available
+	result RunResult
+}
+
+func (m *supervised) start(ctx context.Context, notify func()) {
+	events, unsubscribe := m.p.Subscribe(func(e Event) bool { return e.State.up() || e.State.terminal() })
+	m.up, m.done = make(chan struct{}), make(chan struct{})
+	ctx, m.cancel = context.WithCancel(ctx)
+	results := m.p.Run(ctx)
+	go func() {
+		defer close(m.up)
+		defer unsubscribe()
+		select {
+		case <-events:
+		case <-m.done:
+		}
+	}()
+	go func() {
+		defer notify()
+		defer close(m.done)
+		m.result = <-results
+	}()
+}
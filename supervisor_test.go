@@ -0,0 +1,150 @@
+package process_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andviro/process"
+)
+
+func TestSupervisor(t *testing.T) {
+	var s process.Supervisor
+	for _, name := range []string{"true", "false"} {
+		if err := s.Add(name, process.New("/bin/"+name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Add("true", process.New("/bin/true")); !errors.Is(err, process.ErrDuplicateName) {
+		t.Errorf("%+v", err)
+	}
+	if names := s.Names(); len(names) != 2 || names[0] != "true" || names[1] != "false" {
+		t.Errorf("invalid names: %v", names)
+	}
+	for _, p := range []*process.Process{s.Get("true"), s.Get("false")} {
+		p.StartTimeout = 1000
+		p.MaxStartAttempts = 0
+	}
+	res := s.Run(context.TODO())
+	if res["true"].ExitCode != 0 || res["false"].ExitCode != 1 {
+		t.Errorf("invalid results: %+v", res)
+	}
+}
+
+func TestSupervisorNames(t *testing.T) {
+	var s process.Supervisor
+	w1, w2 := process.New("/bin/sleep", "0.1"), process.New("/bin/sleep", "0.1")
+	w2.Name = "worker"
+	for _, p := range []*process.Process{w1, w2} {
+		if err := s.Add("", p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Add("", process.New("/bin/sleep")); !errors.Is(err, process.ErrDuplicateName) {
+		t.Errorf("%+v", err)
+	}
+	if s.Get("sleep") != w1 || s.Get("worker") != w2 || w1.Name != "sleep" {
+		t.Errorf("invalid names: %v", s.Names())
+	}
+	events, _ := w2.Subscribe(nil)
+	s.Run(context.TODO())
+	for e := range events {
+		if e.Name != "worker" {
+			t.Errorf("invalid event name: %q", e.Name)
+		}
+	}
+}
+
+func TestSupervisorPriority(t *testing.T) {
+	var (
+		s     process.Supervisor
+		mu    sync.Mutex
+		order []string
+	)
+	for _, name := range []string{"app", "db", "proxy"} {
+		name := name
+		priority := map[string]int{"db": 1, "app": 2, "proxy": 3}[name]
+		s.Add(name, &process.Process{Spec: process.Spec{
+			Cmd:          "/bin/sh",
+			Args:         []string{"-c", "exec sleep 3"},
+			StartTimeout: 50,
+			StopTimeout:  1000,
+			Priority:     priority,
+			OnStateChange: func(prev, next process.State, err error, at time.Time) {
+				if next == process.StateStarting || next == process.StateStopped {
+					mu.Lock()
+					defer mu.Unlock()
+					order = append(order, next.String()+" "+name)
+				}
+			},
+		}})
+	}
+	ctx, cancel := context.WithCancel(context.TODO())
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		cancel()
+	}()
+	res := s.Run(ctx)
+	if len(res) != 3 {
+		t.Errorf("invalid results: %+v", res)
+	}
+	expected := "starting db,starting app,starting proxy,stopped proxy,stopped app,stopped db"
+	if got := strings.Join(order, ","); got != expected {
+		t.Errorf("invalid order: %s", got)
+	}
+}
+
+func TestSupervisorAutostart(t *testing.T) {
+	var s process.Supervisor
+	no := false
+	for _, name := range []string{"auto", "manual", "disabled"} {
+		s.Add(name, &process.Process{Spec: process.Spec{
+			Cmd:          "/bin/sh",
+			Args:         []string{"-c", "exec sleep 3"},
+			StartTimeout: 10,
+			StopTimeout:  1000,
+		}})
+	}
+	s.Get("manual").Autostart = &no
+	s.Get("disabled").Enabled = &no
+	if err := s.Start("manual"); !errors.Is(err, process.ErrNotRunning) {
+		t.Errorf("%+v", err)
+	}
+	ctx, cancel := context.WithCancel(context.TODO())
+	done := make(chan map[string]process.RunResult)
+	go func() { done <- s.Run(ctx) }()
+	time.Sleep(50 * time.Millisecond)
+	if st := s.Get("manual").Status().State; st != process.StateIdle {
+		t.Errorf("manual process started: %v", st)
+	}
+	if err := s.Start("disabled"); !errors.Is(err, process.ErrDisabled) {
+		t.Errorf("%+v", err)
+	}
+	if err := s.Start("missing"); !errors.Is(err, process.ErrNoProcess) {
+		t.Errorf("%+v", err)
+	}
+	if err := s.Start("manual"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Start("manual"); !errors.Is(err, process.ErrAlreadyRunning) {
+		t.Errorf("%+v", err)
+	}
+	if err := s.Stop("auto"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if st := s.Get("auto").Status().State; st != process.StateStopped {
+		t.Errorf("auto process not stopped: %v", st)
+	}
+	if st := s.Get("manual").Status().State; st != process.StateRunning {
+		t.Errorf("manual process not running: %v", st)
+	}
+	cancel()
+	res := <-done
+	if _, ok := res["disabled"]; len(res) != 2 || ok {
+		t.Errorf("invalid results: %+v", res)
+	}
+}
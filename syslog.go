@@ -0,0 +1,150 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Syslog severities
+const (
+	SeverityEmerg = iota
+	SeverityAlert
+	SeverityCrit
+	SeverityErr
+	SeverityWarning
+	SeverityNotice
+	SeverityInfo
+	SeverityDebug
+)
+
+// Common syslog facilities
+const (
+	FacilityUser   = 1
+	FacilityDaemon = 3
+	FacilityLocal0 = 16
+)
+
+var syslogSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// SyslogSink forwards child output and lifecycle events to syslog in RFC5424
+// format. Output lines have MSGID set to the stream name, events have MSGID
+// "event".
+type SyslogSink struct {
+	Tag            string // APP-NAME of the messages
+	Facility       int    // Message facility, FacilityUser by default
+	StdoutSeverity int    // Severity of stdout lines, SeverityInfo by default
+	StderrSeverity int    // Severity of stderr lines, SeverityErr by default
+
+	network, addr string
+	hostname      string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// DialSyslog connects to the syslog server. Empty network connects to the
+// local syslog daemon.
+func DialSyslog(network, addr, tag string) (res *SyslogSink, err error) {
+	res = &SyslogSink{
+		Tag:            tag,
+		Facility:       FacilityUser,
+		StdoutSeverity: SeverityInfo,
+		StderrSeverity: SeverityErr,
+		network:        network,
+		addr:           addr,
+	}
+	if res.hostname, err = os.Hostname(); err != nil {
+		res.hostname = "-"
+	}
+	if err = res.dial(); err != nil {
+		return nil, err
+	}
+	return
+}
+
+func (s *SyslogSink) dial() (err error) {
+	if s.network != "" {
+		s.conn, err = net.Dial(s.network, s.addr)
+		return
+	}
+	for _, path := range syslogSockets {
+		for _, network := range []string{"unixgram", "unix"} {
+			if s.conn, err = net.Dial(network, path); err == nil {
+				return
+			}
+		}
+	}
+	return errors.New("local syslog daemon not found")
+}
+
+// Writer returns line-oriented writer for the output stream
+func (s *SyslogSink) Writer(stream Stream) io.Writer {
+	severity := s.StdoutSeverity
+	if stream == StreamStderr {
+		severity = s.StderrSeverity
+	}
+	return &lineWriter{fn: func(line []byte) {
+		s.send(severity, string(stream), string(line))
+	}}
+}
+
+// Event forwards the state change
+func (s *SyslogSink) Event(e Event) {
+	severity := SeverityNotice
+	msg := fmt.Sprintf("%v -> %v", e.Prev, e.State)
+	if e.Err != nil {
+		severity = SeverityWarning
+		msg += ": " + e.Err.Error()
+	}
+	if e.State == StateFailed || e.State == StatePreflightFailed {
+		severity = SeverityErr
+	}
+	s.send(severity, "event", msg)
+}
+
+// Close closes connection to the server
+func (s *SyslogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+func (s *SyslogSink) send(severity int, msgid, msg string) (err error) {
+	tag := s.Tag
+	if tag == "" {
+		tag = "-"
+	}
+	line := fmt.Sprintf("<%d>1 %s %s %s %d %s - %s",
+		s.Facility*8+severity, time.Now().Format("2006-01-02T15:04:05.000000Z07:00"),
+		s.hostname, tag, os.Getpid(), msgid, msg)
+	if strings.HasPrefix(s.network, "tcp") {
+		line = fmt.Sprintf("%d %s", len(line), line)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < 2; i++ {
+		if s.conn == nil {
+			if err = s.dial(); err != nil {
+				continue
+			}
+		}
+		if _, err = io.WriteString(s.conn, line); err == nil {
+			return
+		}
+		s.conn.Close()
+		s.conn = nil
+	}
+	return
+}
@@ -0,0 +1,51 @@
+package process_test
+
+import (
+	"context"
+	"net"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/andviro/process"
+)
+
+func TestSyslogSink(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	defer conn.Close()
+	sink, err := process.DialSyslog("udp", conn.LocalAddr().String(), "worker")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	defer sink.Close()
+	sink.Facility = process.FacilityLocal0
+
+	p := &process.Process{Spec: process.Spec{
+		Cmd:  "/bin/sh",
+		Args: []string{"-c", "echo hello; echo oops >&2; sleep 0.1"},
+		Sink: sink,
+	}}
+	<-p.Run(context.TODO())
+
+	expected := map[string]*regexp.Regexp{
+		"stdout": regexp.MustCompile(`^<134>1 \S+ \S+ worker \d+ stdout - hello$`),
+		"stderr": regexp.MustCompile(`^<131>1 \S+ \S+ worker \d+ stderr - oops$`),
+		"event":  regexp.MustCompile(`^<133>1 \S+ \S+ worker \d+ event - idle -> starting$`),
+	}
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	for len(expected) > 0 {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("messages not received: %v, %+v", expected, err)
+		}
+		for k, re := range expected {
+			if re.Match(buf[:n]) {
+				delete(expected, k)
+			}
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package process
+
+import (
+	"time"
+)
+
+// after returns a channel receiving the time after the timeout in
+// milliseconds. State functions run one at a time, so a single timer is
+// reused instead of allocating one per select that is not collected until
+// it fires.
+func (p *Process) after(timeout int) <-chan time.Time {
+	d := time.Duration(timeout) * time.Millisecond
+	if p.timer == nil {
+		p.timer = time.NewTimer(d)
+		return p.timer.C
+	}
+	if !p.timer.Stop() {
+		select {
+		case <-p.timer.C:
+		default:
+		}
+	}
+	p.timer.Reset(d)
+	return p.timer.C
+}
+
+// stopTimer releases the timer after the run
+func (p *Process) stopTimer() {
+	if p.timer != nil {
+		p.timer.Stop()
+	}
+}
@@ -0,0 +1,38 @@
+package process
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// TLSConfig returns TLS configuration of a control API server, e.g. for
+// http.Server TLSConfig, with the certificate and key read from PEM files.
+// If caFile is not empty, clients must present certificates signed by one
+// of the CAs in the PEM file; their common names are matched against
+// Auth.Clients.
+func TLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("tls: %w", err)
+	}
+	res := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if caFile == "" {
+		return res, nil
+	}
+	data, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("tls: %w", err)
+	}
+	res.ClientCAs = x509.NewCertPool()
+	if !res.ClientCAs.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("tls: %s: %w", caFile, errors.New("no certificates found"))
+	}
+	res.ClientAuth = tls.RequireAndVerifyClientCert
+	return res, nil
+}
@@ -0,0 +1,88 @@
+package process
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Trigger actions
+const (
+	ActionRestart   = "restart"   // Gracefully restart the process
+	ActionUnhealthy = "unhealthy" // Mark the process unhealthy until the next start
+)
+
+// Trigger binds an action to lines of child output matching the pattern
+type Trigger struct {
+	Stream  Stream `json:"stream"`  // Stream to watch, both streams if empty
+	Pattern string `json:"pattern"` // Regular expression matched against every line
+	Action  string `json:"action"`  // One of: "restart", "unhealthy"
+}
+
+func (t Trigger) validate() error {
+	_, err := t.compile()
+	return err
+}
+
+// compile checks the trigger and returns the compiled pattern
+func (t Trigger) compile() (*regexp.Regexp, error) {
+	switch t.Stream {
+	case "", StreamStdout, StreamStderr:
+	default:
+		return nil, fmt.Errorf("unknown stream %q", t.Stream)
+	}
+	switch t.Action {
+	case ActionRestart, ActionUnhealthy:
+	default:
+		return nil, fmt.Errorf("unknown action %q", t.Action)
+	}
+	return regexp.Compile(t.Pattern)
+}
+
+type compiledTrigger struct {
+	Trigger
+	re *regexp.Regexp
+}
+
+type compiledTriggers []compiledTrigger
+
+func compileTriggers(src []Trigger) (res compiledTriggers, err error) {
+	for _, t := range src {
+		re, err := t.compile()
+		if err != nil {
+			return nil, fmt.Errorf("trigger %q: %w", t.Pattern, err)
+		}
+		res = append(res, compiledTrigger{Trigger: t, re: re})
+	}
+	return
+}
+
+func (ts compiledTriggers) forStream(stream Stream) (res []compiledTrigger) {
+	for _, t := range ts {
+		if t.Stream == "" || t.Stream == stream {
+			res = append(res, t)
+		}
+	}
+	return
+}
+
+// trigger is called by the output writers for every line matching the trigger
+func (p *Process) trigger(reqs requests, t Trigger, line []byte) {
+	p.act(reqs, t.Action, fmt.Sprintf("output matched %q", t.Pattern), string(line))
+}
+
+// act performs the action for the reason, detail is logged on restart and
+// kept as the unhealthy status
+func (p *Process) act(reqs requests, action, reason, detail string) {
+	switch action {
+	case ActionRestart:
+		reqs.send(request{
+			restart: true,
+			err:     fmt.Errorf("%w: %s", ErrTriggered, reason),
+			msg:     fmt.Sprintf("%s, restarting: %s", reason, detail),
+		})
+	case ActionUnhealthy:
+		p.mu.Lock()
+		p.unhealthy = detail
+		p.mu.Unlock()
+	}
+}
@@ -0,0 +1,77 @@
+package process_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/andviro/process"
+)
+
+func TestTriggerRestart(t *testing.T) {
+	var stdout syncBuffer
+	p := &process.Process{Spec: process.Spec{
+		Cmd:            "/bin/sh",
+		Args:           []string{"-c", "echo started; sleep 0.2; echo java.lang.OutOfMemoryError >&2; exec sleep 5"},
+		Stdout:         &stdout,
+		StartTimeout:   100,
+		StopTimeout:    1000,
+		MaxRestarts:    1,
+		RestartTimeout: 10,
+		Triggers: []process.Trigger{
+			{Stream: process.StreamStdout, Pattern: "OutOfMemoryError", Action: process.ActionRestart},
+			{Stream: process.StreamStderr, Pattern: "OutOfMemoryError", Action: process.ActionRestart},
+		},
+	}}
+	select {
+	case res := <-p.Run(context.TODO()):
+		if res.Restarts != 2 || res.Attempts != 2 {
+			t.Errorf("invalid result: %+v", res)
+		}
+		if !errors.Is(res.Err, process.ErrMaxRestarts) || !errors.Is(res.Err, process.ErrTriggered) {
+			t.Errorf("%#v", res.Err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("process not restarted")
+	}
+	if out := stdout.String(); out != "started\nstarted\n" {
+		t.Errorf("invalid output: %q", out)
+	}
+}
+
+func TestTriggerUnhealthy(t *testing.T) {
+	spec := process.Spec{
+		Cmd:          "/bin/sh",
+		Args:         []string{"-c", "sleep 0.1; echo 'panic: boom'; exec sleep 5"},
+		StartTimeout: 50,
+		StopTimeout:  1000,
+		Triggers: []process.Trigger{
+			{Pattern: "^panic:", Action: process.ActionUnhealthy},
+		},
+	}
+	h := spec.Run(context.TODO())
+	defer h.Stop()
+	deadline := time.Now().Add(time.Second)
+	for h.Status().Unhealthy == "" {
+		if time.Now().After(deadline) {
+			t.Fatal("process not marked unhealthy")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if st := h.Status(); st.Unhealthy != "panic: boom" || st.State != process.StateRunning {
+		t.Errorf("invalid status: %+v", st)
+	}
+}
+
+func TestTriggerValidate(t *testing.T) {
+	s := process.NewSpec("/bin/true")
+	s.Triggers = []process.Trigger{{Pattern: "(", Action: process.ActionRestart}, {Pattern: "a", Action: "explode"}}
+	var verr *process.ValidationError
+	if !errors.As(s.Validate(), &verr) || len(verr.Errors) != 2 {
+		t.Errorf("invalid validation result: %v", s.Validate())
+	}
+	if res := <-(&process.Process{Spec: s}).Run(context.TODO()); res.State != process.StateFailed || res.Attempts != 0 {
+		t.Errorf("invalid result: %+v", res)
+	}
+}
@@ -0,0 +1,185 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ValidationError lists all configuration problems found by Validate
+type ValidationError struct {
+	Errors []error
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return "invalid process spec: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the list of found problems
+func (e *ValidationError) Unwrap() []error {
+	return e.Errors
+}
+
+// Validate checks the configuration for mistakes. The returned error is a
+// *ValidationError listing every problem found.
+func (s Spec) Validate() error {
+	var errs []error
+	if s.Cmd == "" {
+		errs = append(errs, errors.New("cmd: must be set"))
+	}
+	switch s.CmdLookup {
+	case "", LookupPath, LookupDir:
+	case LookupNone:
+		if _, err := s.cmdPath(s.Cmd); err != nil && s.Cmd != "" && !s.ExpandEnv {
+			errs = append(errs, fmt.Errorf("cmd: %w", err))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("cmdLookup: unknown mode %q", s.CmdLookup))
+	}
+	switch s.RestartPolicy {
+	case "", "always", "on-failure":
+	default:
+		errs = append(errs, fmt.Errorf("restartPolicy: unknown policy %q", s.RestartPolicy))
+	}
+	for _, t := range []struct {
+		name  string
+		value int
+	}{
+		{"startTimeout", s.StartTimeout},
+		{"backoffTimeout", s.BackoffTimeout},
+		{"stopTimeout", s.StopTimeout},
+		{"killTimeout", s.KillTimeout},
+		{"restartTimeout", s.RestartTimeout},
+		{"captureOutput", s.CaptureOutput},
+		{"watchChildren", s.WatchChildren},
+		{"watchDelay", s.WatchDelay},
+		{"drainTimeout", s.DrainTimeout},
+	} {
+		if t.value < 0 {
+			errs = append(errs, fmt.Errorf("%s: must not be negative", t.name))
+		}
+	}
+	if s.MaxStartAttempts < -1 {
+		errs = append(errs, errors.New("maxStartAttempts: must be -1 or greater"))
+	}
+	if s.MaxRestarts < -1 {
+		errs = append(errs, errors.New("maxRestarts: must be -1 or greater"))
+	}
+	if s.Jitter < 0 || s.Jitter > 1 {
+		errs = append(errs, errors.New("jitter: must be from 0 to 1"))
+	}
+	if s.StdoutLimit < 0 {
+		errs = append(errs, errors.New("stdoutLimit: must not be negative"))
+	}
+	if s.StderrLimit < 0 {
+		errs = append(errs, errors.New("stderrLimit: must not be negative"))
+	}
+	for i, code := range s.SuccessExitCodes {
+		if code < 0 {
+			errs = append(errs, fmt.Errorf("successExitCodes[%d]: must not be negative", i))
+		}
+	}
+	if _, ok := logLevels[s.LogLevel]; !ok {
+		errs = append(errs, fmt.Errorf("logLevel: unknown level %q", s.LogLevel))
+	}
+	if s.OutputBuffer < 0 {
+		errs = append(errs, errors.New("outputBuffer: must not be negative"))
+	}
+	switch s.OutputOverflow {
+	case "", OverflowBlock, OverflowDrop, OverflowKill:
+	default:
+		errs = append(errs, fmt.Errorf("outputOverflow: unknown action %q", s.OutputOverflow))
+	}
+	for _, f := range []struct {
+		name   string
+		action string
+	}{
+		{"outputLimitAction", s.OutputLimitAction},
+		{"stdoutPolicy.limitAction", s.streamPolicy(StreamStdout).LimitAction},
+		{"stderrPolicy.limitAction", s.streamPolicy(StreamStderr).LimitAction},
+	} {
+		switch f.action {
+		case "", LimitTruncate, LimitRotate, LimitKill:
+		default:
+			errs = append(errs, fmt.Errorf("%s: unknown action %q", f.name, f.action))
+		}
+	}
+	if _, err := compileReadyPattern(s.ReadyPattern); err != nil {
+		errs = append(errs, fmt.Errorf("readyPattern: %w", err))
+	}
+	if err := s.validateReady(); err != nil {
+		errs = append(errs, fmt.Errorf("readyMode: %w", err))
+	}
+	if s.LineFormat != nil {
+		if _, err := s.LineFormat.compile(); err != nil {
+			errs = append(errs, fmt.Errorf("lineFormat: %w", err))
+		}
+	}
+	if s.DrainSignal != "" {
+		if _, err := parseSignal(s.DrainSignal); err != nil {
+			errs = append(errs, fmt.Errorf("drainSignal: %w", err))
+		}
+	}
+	switch s.Elevate {
+	case "", ElevateSudo, ElevateDoas:
+	default:
+		errs = append(errs, fmt.Errorf("elevate: unknown tool %q", s.Elevate))
+	}
+	for _, f := range []struct {
+		name     string
+		patterns []string
+	}{
+		{"passEnv", s.PassEnv},
+		{"blockEnv", s.BlockEnv},
+		{"redactArgs", s.RedactArgs},
+	} {
+		for i, pattern := range f.patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				errs = append(errs, fmt.Errorf("%s[%d]: %w", f.name, i, err))
+			}
+		}
+	}
+	for i, pattern := range s.WatchPaths {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			errs = append(errs, fmt.Errorf("watchPaths[%d]: %w", i, err))
+		}
+	}
+	if s.CPUThreshold != nil {
+		if err := s.CPUThreshold.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("cpuThreshold: %w", err))
+		}
+	}
+	if s.FDThreshold != nil {
+		if err := s.FDThreshold.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("fdThreshold: %w", err))
+		}
+	}
+	if s.StdinFile != "" && s.StdinFunc != nil {
+		errs = append(errs, errors.New("stdinFile: must not be set with StdinFunc"))
+	}
+	if s.Profiler != nil {
+		if err := s.Profiler.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("profiler: %w", err))
+		}
+	}
+	for i, step := range s.StopSequence {
+		if err := step.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("stopSequence[%d]: %w", i, err))
+		}
+	}
+	for i, t := range s.Triggers {
+		if err := t.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("triggers[%d]: %w", i, err))
+		}
+	}
+	if errs != nil {
+		return &ValidationError{Errors: errs}
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package process_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/andviro/process"
+)
+
+func TestValidate(t *testing.T) {
+	s := process.NewSpec("/bin/true")
+	if err := s.Validate(); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if err := process.New("/bin/true").Validate(); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	s = process.Spec{
+		RestartPolicy: "on-error",
+		StopTimeout:   -1,
+		MaxRestarts:   -2,
+	}
+	var verr *process.ValidationError
+	if !errors.As(s.Validate(), &verr) {
+		t.Fatal("expected validation error")
+	}
+	if len(verr.Errors) != 4 {
+		t.Errorf("invalid errors: %v", verr)
+	}
+}
@@ -0,0 +1,37 @@
+package process
+
+import (
+	"context"
+)
+
+// Done returns a channel closed when the current run is finished. The
+// channel is nil before the first Run.
+func (p *Process) Done() <-chan struct{} {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.done
+}
+
+// Wait blocks until the current run is finished and returns its error.
+// Waiting for a process that has never been run results in ErrNotRunning.
+func (p *Process) Wait(ctx context.Context) error {
+	done := p.Done()
+	if done == nil {
+		return ErrNotRunning
+	}
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.err
+}
+
+// RunWait runs the process to completion and returns the outcome along with
+// its error, a shorthand for receiving from Run
+func (p *Process) RunWait(ctx context.Context) (RunResult, error) {
+	res := <-p.Run(ctx)
+	return res, res.Err
+}
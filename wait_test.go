@@ -0,0 +1,48 @@
+package process_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/andviro/process"
+)
+
+func TestWait(t *testing.T) {
+	p := &process.Process{Spec: process.Spec{
+		Cmd:  "/bin/sh",
+		Args: []string{"-c", "sleep 0.2; exit 1"},
+	}}
+	if err := p.Wait(context.TODO()); !errors.Is(err, process.ErrNotRunning) {
+		t.Errorf("%v", err)
+	}
+	p.Run(context.TODO())
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("%v", err)
+	}
+	var exitErr *process.ExitError
+	if err := p.Wait(context.TODO()); !errors.As(err, &exitErr) {
+		t.Errorf("%v", err)
+	}
+	select {
+	case <-p.Done():
+	default:
+		t.Error("done channel is not closed")
+	}
+}
+
+func TestRunWait(t *testing.T) {
+	p := &process.Process{Spec: process.Spec{Cmd: "/bin/sh", Args: []string{"-c", "exit 2"}}}
+	res, err := p.RunWait(context.TODO())
+	var exitErr *process.ExitError
+	if !errors.As(err, &exitErr) || res.ExitCode != 2 || res.Reason != process.ReasonCrashed {
+		t.Errorf("invalid result: %+v", res)
+	}
+	p = &process.Process{Spec: process.Spec{Cmd: "/bin/true"}}
+	if res, err = p.RunWait(context.TODO()); err != nil || res.ExitCode != 0 {
+		t.Errorf("invalid result: %+v", res)
+	}
+}
@@ -0,0 +1,78 @@
+package process
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const defaultWatchDelay = 500
+
+type fileStamp struct {
+	mod  time.Time
+	size int64
+}
+
+// watchPaths polls files matching WatchPaths and requests a restart once
+// changes settle down for WatchDelay, until done is closed
+func (p *Process) watchPaths(reqs requests, done <-chan struct{}) {
+	pollChanges(p.WatchPaths, p.WatchDelay, done, func(path string) {
+		reqs.send(request{restart: true, msg: "watched path changed, restarting: " + path})
+	})
+}
+
+// pollChanges polls files matching the patterns every delay ms and calls fn
+// with a changed path once no more changes are seen, until done is closed
+func pollChanges(patterns []string, delay int, done <-chan struct{}, fn func(path string)) {
+	if delay == 0 {
+		delay = defaultWatchDelay
+	}
+	ticker := time.NewTicker(time.Duration(delay) * time.Millisecond)
+	defer ticker.Stop()
+	known := statGlobs(patterns)
+	var changed string
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+		current := statGlobs(patterns)
+		if path := diffStamps(known, current); path != "" {
+			known, changed = current, path
+			continue
+		}
+		if changed != "" {
+			fn(changed)
+			changed = ""
+		}
+	}
+}
+
+func statGlobs(patterns []string) map[string]fileStamp {
+	res := make(map[string]fileStamp)
+	for _, pattern := range patterns {
+		matches, _ := filepath.Glob(pattern)
+		for _, path := range matches {
+			if fi, err := os.Stat(path); err == nil {
+				res[path] = fileStamp{mod: fi.ModTime(), size: fi.Size()}
+			}
+		}
+	}
+	return res
+}
+
+// diffStamps returns a path that was changed, created or removed
+func diffStamps(prev, next map[string]fileStamp) string {
+	for path, st := range next {
+		if old, ok := prev[path]; !ok || old != st {
+			return path
+		}
+	}
+	for path := range prev {
+		if _, ok := next[path]; !ok {
+			return path
+		}
+	}
+	return ""
+}
@@ -0,0 +1,42 @@
+package process_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/andviro/process"
+)
+
+func TestWatchPaths(t *testing.T) {
+	dir := t.TempDir()
+	conf := filepath.Join(dir, "app.conf")
+	if err := os.WriteFile(conf, []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p := &process.Process{Spec: process.Spec{
+		Cmd:            "/bin/sh",
+		Args:           []string{"-c", "exec sleep 3"},
+		StartTimeout:   10,
+		StopTimeout:    1000,
+		RestartTimeout: 10,
+		MaxRestarts:    -1,
+		WatchPaths:     []string{filepath.Join(dir, "*.conf")},
+		WatchDelay:     30,
+	}}
+	ctx, cancel := context.WithCancel(context.TODO())
+	res := p.Run(ctx)
+	time.Sleep(100 * time.Millisecond)
+	for i := 0; i < 5; i++ {
+		os.WriteFile(conf, []byte("ab"[:1+i%2]), 0644)
+		time.Sleep(10 * time.Millisecond)
+	}
+	os.WriteFile(filepath.Join(dir, "other.conf"), nil, 0644)
+	time.Sleep(200 * time.Millisecond)
+	cancel()
+	if r := <-res; r.Restarts != 1 {
+		t.Errorf("invalid restarts: %d", r.Restarts)
+	}
+}